@@ -0,0 +1,184 @@
+// Package cli implements the non-interactive autonomix-cli subcommands.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/manager"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK    = 0
+	ExitError = 1
+	ExitUsage = 2
+	// ExitUpdatesAvailable is returned by "check" when at least one app has
+	// an update, following the dnf check-update convention.
+	ExitUpdatesAvailable = 100
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(e *env, args []string) int
+}
+
+// env carries what every command needs.
+type env struct {
+	version string
+	stdout  io.Writer
+	stderr  io.Writer
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"list", "", "List tracked apps and their status", runList},
+		{"add", "<repo-url>", "Start tracking a GitHub repository", runAdd},
+		{"remove", "<app>", "Stop tracking an app, optionally uninstalling it", runRemove},
+		{"check", "[app]", "Check GitHub for new releases", runCheck},
+		{"install", "<app|--locked>", "Install the latest or a specific release of an app", runInstall},
+		{"update", "[app|--all]", "Update one or all apps with an available update", runUpdate},
+		{"info", "<app>", "Show details about a tracked app", runInfo},
+		{"pin", "<app> [version]", "Hold an app at a version", runPin},
+		{"unpin", "<app>", "Release the hold on an app", runUnpin},
+		{"sync", "<manifest>", "Track and install the apps listed in a manifest", runSync},
+		{"lock", "[app]", "Show or update the lockfile of installed assets", runLock},
+		{"cache", "clean", "Remove cached downloads", runCache},
+		{"version", "", "Print the autonomix-cli version", runVersion},
+	}
+}
+
+// Run executes the subcommand in args (os.Args[1:]) and returns the exit code.
+// A bare GitHub URL is accepted as shorthand for "add <url>".
+func Run(args []string, version string) int {
+	e := &env{version: version, stdout: os.Stdout, stderr: os.Stderr}
+
+	if len(args) == 0 {
+		usage(e.stderr)
+		return ExitUsage
+	}
+
+	name := args[0]
+	switch name {
+	case "-h", "--help", "help":
+		usage(e.stdout)
+		return ExitOK
+	case "-v", "--version":
+		return runVersion(e, nil)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(e, args[1:])
+		}
+	}
+
+	// "autonomix-cli https://github.com/owner/repo"
+	if strings.Contains(name, "github.com/") {
+		return runAdd(e, args)
+	}
+
+	fmt.Fprintf(e.stderr, "Unknown command: %s\n\n", name)
+	usage(e.stderr)
+	return ExitUsage
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: autonomix-cli [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the interactive TUI is started.")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-28s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'autonomix-cli <command> --help' for the flags of a command.")
+}
+
+// newFlagSet returns a FlagSet whose --help output lists the command's usage.
+// Requested help goes to stdout, usage errors to stderr.
+func newFlagSet(e *env, name string, args []string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	for _, arg := range args {
+		if arg == "-h" || arg == "-help" || arg == "--help" {
+			fs.SetOutput(e.stdout)
+		}
+	}
+	fs.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(fs.Output(), "Usage: autonomix-cli %s\n\n%s\n", strings.TrimSpace(name+" "+cmd.args), cmd.summary)
+			}
+		}
+		var hasFlags bool
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(fs.Output(), "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// parseFlags parses flags interspersed with positional arguments and checks
+// the number of positional arguments. It returns the positional arguments,
+// or a non-negative exit code if the command should stop.
+func parseFlags(fs *flag.FlagSet, args []string, minArgs, maxArgs int) ([]string, int) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			// The FlagSet has already printed the usage or the error
+			if errors.Is(err, flag.ErrHelp) {
+				return nil, ExitOK
+			}
+			return nil, ExitUsage
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) < minArgs || (maxArgs >= 0 && len(positional) > maxArgs) {
+		fs.Usage()
+		return nil, ExitUsage
+	}
+	return positional, -1
+}
+
+// loadConfig loads the config and registers its global settings. A config
+// that had to be quarantined is reported and replaced by an empty one.
+func loadConfig(e *env) (*config.Config, bool) {
+	cfg, err := manager.LoadConfig()
+	var quarantined *config.QuarantineError
+	if errors.As(err, &quarantined) {
+		// The unreadable file was moved aside; carry on with an empty config
+		fmt.Fprintf(e.stderr, "Warning: %v\n", err)
+	} else if err != nil {
+		fmt.Fprintf(e.stderr, "Error loading config: %v\n", err)
+		return nil, false
+	}
+	return cfg, true
+}
+
+// updateConfig saves a change to the config, see manager.UpdateConfig.
+func updateConfig(e *env, cfg *config.Config, fn func(cfg *config.Config) error) bool {
+	if err := manager.UpdateConfig(cfg, fn); err != nil {
+		fmt.Fprintf(e.stderr, "Error saving config: %v\n", err)
+		return false
+	}
+	return true
+}
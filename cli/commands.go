@@ -0,0 +1,753 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/installer"
+	"github.com/tim/autonomix-cli/pkg/lockfile"
+	"github.com/tim/autonomix-cli/pkg/manager"
+	"github.com/tim/autonomix-cli/pkg/manifest"
+	"github.com/tim/autonomix-cli/pkg/verify"
+)
+
+func runList(e *env, args []string) int {
+	fs := newFlagSet(e, "list", args)
+	output := outputFlag(fs)
+	if _, code := parseFlags(fs, args, 0, 0); code >= 0 {
+		return code
+	}
+	if !validOutput(e, *output) {
+		return ExitUsage
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+
+	if *output != outputText {
+		return reportOrFail(e, *output, cfg.Apps)
+	}
+
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tINSTALLED\tLATEST\tSTATUS")
+	for _, app := range cfg.Apps {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", app.Name, orDash(app.Version), orDash(app.Latest), manager.Status(app))
+	}
+	w.Flush()
+	return ExitOK
+}
+
+func runAdd(e *env, args []string) int {
+	fs := newFlagSet(e, "add", args)
+	channel := fs.String("channel", "", `release channel: "stable", "prerelease" or a tag regex`)
+	pos, code := parseFlags(fs, args, 1, 1)
+	if code >= 0 {
+		return code
+	}
+	if err := github.ValidateChannel(*channel); err != nil {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return ExitUsage
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+
+	fmt.Fprintf(e.stdout, "Adding repository: %s...\n", pos[0])
+	res, err := manager.AddApp(cfg, pos[0])
+	if err != nil {
+		if res != nil && !res.Created {
+			fmt.Fprintf(e.stdout, "Repository %s is already tracked.\n", res.App.Name)
+			return ExitOK
+		}
+		fmt.Fprintf(e.stderr, "Error adding app: %v\n", err)
+		return ExitError
+	}
+
+	if *channel != "" {
+		ok := updateConfig(e, cfg, func(cfg *config.Config) error {
+			idx, err := manager.FindApp(cfg, res.App.RepoURL)
+			if err != nil {
+				return err
+			}
+			cfg.Apps[idx].Channel = *channel
+			return nil
+		})
+		if !ok {
+			return ExitError
+		}
+	}
+	fmt.Fprintf(e.stdout, "Successfully added %s (Latest: %s)\n", res.App.Name, res.App.Latest)
+	return ExitOK
+}
+
+func runRemove(e *env, args []string) int {
+	fs := newFlagSet(e, "remove", args)
+	uninstall := fs.Bool("uninstall", false, "also uninstall the app through its package manager")
+	pos, code := parseFlags(fs, args, 1, 1)
+	if code >= 0 {
+		return code
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+	idx, err := manager.FindApp(cfg, pos[0])
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return ExitError
+	}
+
+	if *uninstall {
+		cmd, err := manager.UninstallCmd(cfg.Apps[idx])
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error: %v\n", err)
+			return ExitError
+		}
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, e.stdout, e.stderr
+		fmt.Fprintf(e.stdout, "Uninstalling %s...\n", cfg.Apps[idx].Name)
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(e.stderr, "Error uninstalling: %v\n", err)
+			return ExitError
+		}
+	}
+
+	app, err := manager.RemoveApp(cfg, cfg.Apps[idx].RepoURL)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(e.stdout, "%s is no longer tracked\n", app.Name)
+	return ExitOK
+}
+
+func runCheck(e *env, args []string) int {
+	fs := newFlagSet(e, "check", args)
+	output := outputFlag(fs)
+	pos, code := parseFlags(fs, args, 0, 1)
+	if code >= 0 {
+		return code
+	}
+	if !validOutput(e, *output) {
+		return ExitUsage
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+
+	indexes, code := selectApps(e, cfg, pos)
+	if code >= 0 {
+		return code
+	}
+
+	failed, updates := false, false
+	// In machine-readable mode the table is discarded and a report printed instead
+	var table io.Writer = e.stdout
+	if *output != outputText {
+		table = io.Discard
+	}
+	w := tabwriter.NewWriter(table, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tINSTALLED\tLATEST\tSTATUS")
+	for _, idx := range indexes {
+		app := &cfg.Apps[idx]
+		if app.Pinned && len(pos) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", app.Name, orDash(app.Version), orDash(app.Latest), manager.StatusHeld)
+			continue
+		}
+		rel, err := github.FetchChannelRelease(app.RepoURL, app.Channel, true)
+		manager.RecordCheck(app, rel, err)
+		if err != nil {
+			failed = true
+			fmt.Fprintf(w, "%s\t%s\t%s\terror: %v\n", app.Name, orDash(app.Version), orDash(app.Latest), err)
+			continue
+		}
+		status := manager.Status(*app)
+		if status == manager.StatusUpdateAvailable {
+			updates = true
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", app.Name, orDash(app.Version), app.Latest, status)
+	}
+	w.Flush()
+
+	var checked []config.App
+	for _, idx := range indexes {
+		checked = append(checked, cfg.Apps[idx])
+	}
+	if *output != outputText {
+		if code := reportOrFail(e, *output, checked); code != ExitOK {
+			return code
+		}
+	}
+
+	saved := updateConfig(e, cfg, func(cfg *config.Config) error {
+		for _, app := range checked {
+			manager.CopyCheck(cfg, app)
+		}
+		return nil
+	})
+	if !saved || failed {
+		return ExitError
+	}
+	if updates {
+		return ExitUpdatesAvailable
+	}
+	return ExitOK
+}
+
+func runInstall(e *env, args []string) int {
+	fs := newFlagSet(e, "install", args)
+	tag := fs.String("version", "", "release tag to install (default: latest on the app's channel)")
+	force := fs.Bool("force", false, "install even if the app is held at another version")
+	locked := fs.Bool("locked", false, "install exactly the assets recorded in the lockfile, all apps without an argument")
+	lockPath := fs.String("lockfile", lockfile.DefaultPath, "lockfile read by --locked")
+	pos, code := parseFlags(fs, args, 0, 1)
+	if code >= 0 {
+		return code
+	}
+	if *locked {
+		if *tag != "" {
+			fmt.Fprintln(e.stderr, "Error: --locked and --version cannot be combined")
+			return ExitUsage
+		}
+		return runLockedInstall(e, pos, *lockPath, *force)
+	}
+	if len(pos) != 1 {
+		fs.Usage()
+		return ExitUsage
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+	idx, err := manager.FindApp(cfg, pos[0])
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	app := cfg.Apps[idx]
+	if app.Pinned && !*force && *tag == "" {
+		fmt.Fprintf(e.stderr, "%s is held at %s, use --force or --version to install anyway\n", app.Name, app.HoldVersion)
+		return ExitError
+	}
+
+	rel, err := manager.ResolveRelease(app, *tag, true)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	if *tag == "" {
+		// Apply saves it with the installed version
+		app.Latest = rel.TagName
+	}
+
+	fmt.Fprintf(e.stdout, "Installing %s %s...\n", app.Name, rel.TagName)
+	plan := manager.PrepareInstall(app, rel, downloadProgress(e))
+	return runPlan(e, cfg, plan)
+}
+
+// runLockedInstall installs the assets the lockfile at path records for this
+// platform, or only the one of the app named in pos. Locked apps that are not
+// tracked yet are added first.
+func runLockedInstall(e *env, pos []string, path string, force bool) int {
+	lf, err := lockfile.Load(path)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading lockfile: %v\n", err)
+		return ExitError
+	}
+	platform := lockfile.Platform()
+	entries := lf.ForPlatform(platform)
+	if len(pos) == 1 {
+		// Match the argument like a tracked app
+		locked := &config.Config{}
+		for _, entry := range entries {
+			locked.Apps = append(locked.Apps, config.App{Name: entry.Name, RepoURL: entry.Repo})
+		}
+		idx, err := manager.FindApp(locked, pos[0])
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error: %s: %v for %s\n", path, err, platform)
+			return ExitError
+		}
+		entries = entries[idx : idx+1]
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(e.stderr, "Error: %s locks no apps for %s\n", path, platform)
+		return ExitError
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+
+	var missing []config.App
+	for _, entry := range entries {
+		if _, err := manager.FindApp(cfg, entry.Repo); err != nil {
+			app := config.App{Name: entry.Name, RepoURL: entry.Repo}
+			if inst, installed := manager.DetectInstalled(app); installed {
+				manager.RecordInstallation(&app, inst)
+			}
+			missing = append(missing, app)
+		}
+	}
+	if len(missing) > 0 {
+		ok := updateConfig(e, cfg, func(cfg *config.Config) error {
+			for _, app := range missing {
+				if _, err := manager.FindApp(cfg, app.RepoURL); err != nil {
+					cfg.Apps = append(cfg.Apps, app)
+				}
+			}
+			return nil
+		})
+		if !ok {
+			return ExitError
+		}
+	}
+
+	fmt.Fprintf(e.stdout, "Installing %d locked app(s) from %s...\n", len(entries), path)
+	plan := &manager.UpdatePlan{}
+	for _, entry := range entries {
+		idx, err := manager.FindApp(cfg, entry.Repo)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error: %v\n", err)
+			return ExitError
+		}
+		plan.Results = append(plan.Results, manager.PrepareLocked(cfg.Apps[idx], entry, force, downloadProgress(e)).Results...)
+	}
+	return runPlan(e, cfg, plan)
+}
+
+func runUpdate(e *env, args []string) int {
+	fs := newFlagSet(e, "update", args)
+	all := fs.Bool("all", false, "update every tracked app with an available update")
+	force := fs.Bool("force", false, "also update held apps")
+	pos, code := parseFlags(fs, args, 0, 1)
+	if code >= 0 {
+		return code
+	}
+	if *all == (len(pos) == 1) {
+		fs.Usage()
+		return ExitUsage
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+
+	indexes, code := selectApps(e, cfg, pos)
+	if code >= 0 {
+		return code
+	}
+	var apps []config.App
+	for _, idx := range indexes {
+		apps = append(apps, cfg.Apps[idx])
+	}
+
+	fmt.Fprintln(e.stdout, "Checking for updates...")
+	return runPlan(e, cfg, manager.PrepareUpdates(apps, *force, downloadProgress(e)))
+}
+
+// runPlan installs the pending packages of plan in one batch, saves the
+// resulting versions and prints the per-app summary.
+func runPlan(e *env, cfg *config.Config, plan *manager.UpdatePlan) int {
+	var installErr error
+	if plan.Pending() > 0 {
+		fmt.Fprint(e.stdout, plan.Verification())
+		fmt.Fprintf(e.stdout, "Installing %d package(s)...\n", plan.Pending())
+		plan.InstallUserPackages()
+		cmd, err := plan.InstallCmd()
+		switch {
+		case err != nil:
+			installErr = err
+		case cmd != nil:
+			cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, e.stdout, e.stderr
+			installErr = cmd.Run()
+		}
+	}
+	plan.Finish(installErr)
+	saved := updateConfig(e, cfg, func(cfg *config.Config) error {
+		plan.Apply(cfg)
+		return nil
+	})
+
+	fmt.Fprintln(e.stdout)
+	fmt.Fprint(e.stdout, plan.Summary())
+	if plan.Failed() || !saved {
+		return ExitError
+	}
+	return ExitOK
+}
+
+func runInfo(e *env, args []string) int {
+	fs := newFlagSet(e, "info", args)
+	output := outputFlag(fs)
+	pos, code := parseFlags(fs, args, 1, 1)
+	if code >= 0 {
+		return code
+	}
+	if !validOutput(e, *output) {
+		return ExitUsage
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+	idx, err := manager.FindApp(cfg, pos[0])
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error: %v\n", err)
+		return ExitError
+	}
+	app := cfg.Apps[idx]
+
+	if *output != outputText {
+		return reportOrFail(e, *output, []config.App{app})
+	}
+
+	channel := app.Channel
+	if channel == "" {
+		channel = github.ChannelStable
+	}
+	w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", app.Name)
+	fmt.Fprintf(w, "Repository:\t%s\n", app.RepoURL)
+	fmt.Fprintf(w, "Channel:\t%s\n", channel)
+	fmt.Fprintf(w, "Installed:\t%s\n", orDash(app.Version))
+	fmt.Fprintf(w, "Latest:\t%s\n", orDash(app.Latest))
+	fmt.Fprintf(w, "Status:\t%s\n", manager.Status(app))
+	if app.Pinned {
+		fmt.Fprintf(w, "Held at:\t%s\n", app.HoldVersion)
+	}
+	if app.PackageName != "" {
+		fmt.Fprintf(w, "Package:\t%s (%s)\n", app.PackageName, app.PackageType)
+	}
+	if app.PreferredType != "" {
+		fmt.Fprintf(w, "Preferred type:\t%s\n", app.PreferredType)
+	}
+	if len(app.AssetInclude) > 0 {
+		fmt.Fprintf(w, "Include assets:\t%s\n", strings.Join(app.AssetInclude, ", "))
+	}
+	if len(app.AssetExclude) > 0 {
+		fmt.Fprintf(w, "Exclude assets:\t%s\n", strings.Join(app.AssetExclude, ", "))
+	}
+	if app.AssetPattern != "" {
+		fmt.Fprintf(w, "Remembered asset:\t%s\n", app.AssetPattern)
+	}
+	if app.SnapClassic {
+		fmt.Fprintf(w, "Snap:\tclassic confinement\n")
+	}
+	if app.BinaryGlob != "" {
+		fmt.Fprintf(w, "Binary glob:\t%s\n", app.BinaryGlob)
+	}
+	if app.BinDir != "" {
+		fmt.Fprintf(w, "Bin dir:\t%s\n", app.BinDir)
+	}
+	fmt.Fprintf(w, "Signatures:\t%s\n", signatureSummary(app))
+	if app.LastChecked != "" {
+		fmt.Fprintf(w, "Last checked:\t%s\n", app.LastChecked)
+	}
+	if app.LastError != "" {
+		fmt.Fprintf(w, "Last error:\t%s\n", app.LastError)
+	}
+	w.Flush()
+	return ExitOK
+}
+
+func runPin(e *env, args []string) int {
+	fs := newFlagSet(e, "pin", args)
+	pos, code := parseFlags(fs, args, 1, 2)
+	if code >= 0 {
+		return code
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+	holdVersion := ""
+	if len(pos) == 2 {
+		holdVersion = pos[1]
+	}
+	app, err := manager.PinApp(cfg, pos[0], holdVersion)
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error pinning app: %v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(e.stdout, "%s is now held at %s\n", app.Name, app.HoldVersion)
+	return ExitOK
+}
+
+func runUnpin(e *env, args []string) int {
+	fs := newFlagSet(e, "unpin", args)
+	pos, code := parseFlags(fs, args, 1, 1)
+	if code >= 0 {
+		return code
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+	app, err := manager.UnpinApp(cfg, pos[0])
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error unpinning app: %v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(e.stdout, "%s is no longer held\n", app.Name)
+	return ExitOK
+}
+
+func runCache(e *env, args []string) int {
+	fs := newFlagSet(e, "cache", args)
+	pos, code := parseFlags(fs, args, 1, 1)
+	if code >= 0 {
+		return code
+	}
+	if pos[0] != "clean" {
+		fs.Usage()
+		return ExitUsage
+	}
+	freed, err := installer.CleanDownloadCache()
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error cleaning cache: %v\n", err)
+		return ExitError
+	}
+	fmt.Fprintf(e.stdout, "Removed %.1f MiB of cached downloads\n", float64(freed)/(1<<20))
+	return ExitOK
+}
+
+func runSync(e *env, args []string) int {
+	fs := newFlagSet(e, "sync", args)
+	dryRun := fs.Bool("dry-run", false, "show the plan without changing anything")
+	prune := fs.Bool("prune", false, "untrack apps the manifest does not list")
+	uninstall := fs.Bool("uninstall", false, "with --prune, also uninstall them")
+	pos, code := parseFlags(fs, args, 1, 1)
+	if code >= 0 {
+		return code
+	}
+	if *uninstall && !*prune {
+		fmt.Fprintln(e.stderr, "Error: --uninstall requires --prune")
+		return ExitUsage
+	}
+	m, err := manifest.Load(pos[0])
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading manifest: %v\n", err)
+		return ExitError
+	}
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+
+	fmt.Fprintf(e.stdout, "Resolving %d app(s)...\n", len(m.Apps))
+	plan := manager.PlanSync(cfg, m, manager.SyncOptions{Prune: *prune, Uninstall: *uninstall})
+	fmt.Fprint(e.stdout, plan)
+	if *dryRun {
+		if plan.Failed() {
+			return ExitError
+		}
+		return ExitOK
+	}
+	fmt.Fprintln(e.stdout)
+
+	ok = updateConfig(e, cfg, func(cfg *config.Config) error {
+		plan.Configure(cfg)
+		return nil
+	})
+	if !ok {
+		return ExitError
+	}
+	failed := plan.Failed()
+	if installs := plan.PrepareInstalls(downloadProgress(e)); len(installs.Results) > 0 {
+		failed = runPlan(e, cfg, installs) != ExitOK || failed
+	}
+
+	for _, step := range plan.Pruned() {
+		if step.Action == manager.SyncUninstall {
+			cmd, err := manager.UninstallCmd(step.App)
+			if err == nil {
+				cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, e.stdout, e.stderr
+				fmt.Fprintf(e.stdout, "Uninstalling %s...\n", step.App.Name)
+				err = cmd.Run()
+			}
+			if err != nil {
+				// Keep tracking what is still installed
+				fmt.Fprintf(e.stderr, "Error uninstalling %s: %v\n", step.App.Name, err)
+				failed = true
+				continue
+			}
+		}
+		if _, err := manager.RemoveApp(cfg, step.App.RepoURL); err != nil {
+			fmt.Fprintf(e.stderr, "Error: %v\n", err)
+			failed = true
+			continue
+		}
+		fmt.Fprintf(e.stdout, "%s is no longer tracked\n", step.App.Name)
+	}
+
+	if failed {
+		return ExitError
+	}
+	return ExitOK
+}
+
+func runLock(e *env, args []string) int {
+	fs := newFlagSet(e, "lock", args)
+	update := fs.Bool("update", false, "record the assets installed on this machine")
+	path := fs.String("lockfile", lockfile.DefaultPath, "lockfile to show or update")
+	pos, code := parseFlags(fs, args, 0, 1)
+	if code >= 0 {
+		return code
+	}
+	lf, err := lockfile.Load(*path)
+	if os.IsNotExist(err) && *update {
+		lf, err = &lockfile.Lockfile{}, nil
+	}
+	if err != nil {
+		fmt.Fprintf(e.stderr, "Error reading lockfile: %v\n", err)
+		return ExitError
+	}
+
+	if !*update {
+		w := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tPLATFORM\tTAG\tASSET\tSHA256")
+		for _, entry := range lf.Apps {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.12s\n", entry.Name, entry.Platform, entry.Tag, entry.Asset, entry.SHA256)
+		}
+		w.Flush()
+		return ExitOK
+	}
+
+	cfg, ok := loadConfig(e)
+	if !ok {
+		return ExitError
+	}
+	indexes, code := selectApps(e, cfg, pos)
+	if code >= 0 {
+		return code
+	}
+	platform := lockfile.Platform()
+	if len(pos) == 0 {
+		// Drop entries of apps this machine no longer tracks
+		for _, entry := range lf.ForPlatform(platform) {
+			if _, err := manager.FindApp(cfg, entry.Repo); err != nil {
+				lf.Remove(entry.Repo, platform)
+				fmt.Fprintf(e.stdout, "Removed %s\n", entry.Name)
+			}
+		}
+	}
+
+	failed := false
+	for _, idx := range indexes {
+		app := cfg.Apps[idx]
+		if app.Version == "" && len(pos) == 0 {
+			lf.Remove(app.RepoURL, platform)
+			continue
+		}
+		entry, err := manager.LockEntry(app)
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error locking %s: %v\n", app.Name, err)
+			failed = true
+			continue
+		}
+		lf.Set(entry)
+		fmt.Fprintf(e.stdout, "Locked %s %s (%s)\n", app.Name, entry.Tag, entry.Asset)
+	}
+
+	if err := lf.Save(*path); err != nil {
+		fmt.Fprintf(e.stderr, "Error writing lockfile: %v\n", err)
+		return ExitError
+	}
+	if failed {
+		return ExitError
+	}
+	return ExitOK
+}
+
+func runVersion(e *env, args []string) int {
+	fs := newFlagSet(e, "version", args)
+	if _, code := parseFlags(fs, args, 0, 0); code >= 0 {
+		return code
+	}
+	fmt.Fprintf(e.stdout, "autonomix-cli %s\n", e.version)
+	return ExitOK
+}
+
+// selectApps returns the indexes of the app named in pos, or of all apps.
+func selectApps(e *env, cfg *config.Config, pos []string) ([]int, int) {
+	if len(pos) == 1 {
+		idx, err := manager.FindApp(cfg, pos[0])
+		if err != nil {
+			fmt.Fprintf(e.stderr, "Error: %v\n", err)
+			return nil, ExitError
+		}
+		return []int{idx}, -1
+	}
+	indexes := make([]int, len(cfg.Apps))
+	for i := range cfg.Apps {
+		indexes[i] = i
+	}
+	return indexes, -1
+}
+
+func reportOrFail(e *env, format string, apps []config.App) int {
+	if err := writeReport(e.stdout, format, apps); err != nil {
+		fmt.Fprintf(e.stderr, "Error writing output: %v\n", err)
+		return ExitError
+	}
+	return ExitOK
+}
+
+// signatureSummary describes an app's signature policy and pinned keys.
+func signatureSummary(app config.App) string {
+	policy := app.SignaturePolicy
+	if policy == "" {
+		policy = string(verify.PolicyWarn)
+	}
+	var types []string
+	for _, key := range app.PublicKeys {
+		types = append(types, key.Type)
+	}
+	if len(types) == 0 {
+		return policy + ", no keys pinned"
+	}
+	return fmt.Sprintf("%s, keys: %s", policy, strings.Join(types, ", "))
+}
+
+// downloadProgress prints download progress to stderr: one line updated in
+// place on a terminal, otherwise a line as each download starts.
+func downloadProgress(e *env) installer.ProgressFunc {
+	tty := isTerminal(e.stderr)
+	var current string
+	return func(p installer.Progress) {
+		if !tty {
+			if p.Asset != current {
+				current = p.Asset
+				fmt.Fprintf(e.stderr, "Downloading %s...\n", p.Asset)
+			}
+			return
+		}
+		fmt.Fprintf(e.stderr, "\r\033[K%s: %s", p.Asset, p)
+		if p.Cached || p.Total > 0 && p.Done >= p.Total {
+			fmt.Fprintln(e.stderr)
+		}
+	}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
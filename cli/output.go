@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/manager"
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/verify"
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+// reportSchemaVersion is bumped whenever a field of the machine-readable
+// output is removed or changes meaning. New fields may be added freely.
+const reportSchemaVersion = 1
+
+const (
+	outputText = "text"
+	outputJSON = "json"
+	outputYAML = "yaml"
+)
+
+// report is the document printed by --output json/yaml.
+type report struct {
+	SchemaVersion int         `json:"schema_version" yaml:"schema_version"`
+	Apps          []appReport `json:"apps" yaml:"apps"`
+}
+
+// appReport is one tracked app with its computed state.
+type appReport struct {
+	Name            string       `json:"name" yaml:"name"`
+	RepoURL         string       `json:"repo_url" yaml:"repo_url"`
+	Channel         string       `json:"channel" yaml:"channel"`
+	Version         string       `json:"version" yaml:"version"`
+	Latest          string       `json:"latest" yaml:"latest"`
+	LastChecked     string       `json:"last_checked" yaml:"last_checked"`
+	HoldVersion     string       `json:"hold_version" yaml:"hold_version"`
+	PackageType     string       `json:"package_type" yaml:"package_type"`
+	PackageName     string       `json:"package_name" yaml:"package_name"`
+	SignaturePolicy string       `json:"signature_policy" yaml:"signature_policy"`
+	Status          statusReport `json:"status" yaml:"status"`
+	LastError       string       `json:"last_error" yaml:"last_error"`
+}
+
+type statusReport struct {
+	State     string `json:"state" yaml:"state"`
+	Installed bool   `json:"installed" yaml:"installed"`
+	Outdated  bool   `json:"outdated" yaml:"outdated"`
+	Pinned    bool   `json:"pinned" yaml:"pinned"`
+}
+
+func newAppReport(app config.App) appReport {
+	channel := app.Channel
+	if channel == "" {
+		channel = github.ChannelStable
+	}
+	pkgType := app.PackageType
+	if pkgType == "" {
+		pkgType = string(packages.Unknown)
+	}
+	policy := app.SignaturePolicy
+	if policy == "" {
+		policy = string(verify.PolicyWarn)
+	}
+	state := manager.Status(app)
+	return appReport{
+		Name:            app.Name,
+		RepoURL:         app.RepoURL,
+		Channel:         channel,
+		Version:         app.Version,
+		Latest:          app.Latest,
+		LastChecked:     app.LastChecked,
+		HoldVersion:     app.HoldVersion,
+		PackageType:     pkgType,
+		PackageName:     app.PackageName,
+		SignaturePolicy: policy,
+		Status: statusReport{
+			State:     state,
+			Installed: app.Version != "",
+			Outdated:  version.Check(app.Version, app.Latest) == version.UpdateAvailable,
+			Pinned:    app.Pinned,
+		},
+		LastError: app.LastError,
+	}
+}
+
+// outputFlag registers the --output flag on fs.
+func outputFlag(fs *flag.FlagSet) *string {
+	return fs.String("output", outputText, "output format: text, json or yaml")
+}
+
+func validOutput(e *env, format string) bool {
+	switch format {
+	case outputText, outputJSON, outputYAML:
+		return true
+	}
+	fmt.Fprintf(e.stderr, "Error: unknown output format %q (want text, json or yaml)\n", format)
+	return false
+}
+
+// writeReport prints apps in the machine-readable format.
+func writeReport(w io.Writer, format string, apps []config.App) error {
+	r := report{SchemaVersion: reportSchemaVersion, Apps: []appReport{}}
+	for _, app := range apps {
+		r.Apps = append(r.Apps, newAppReport(app))
+	}
+
+	if format == outputYAML {
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
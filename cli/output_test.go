@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/tim/autonomix-cli/config"
+)
+
+// TestWriteReport_Schema guards the documented field names of --output json.
+func TestWriteReport_Schema(t *testing.T) {
+	apps := []config.App{{
+		Name:        "tool",
+		RepoURL:     "https://github.com/owner/tool",
+		Version:     "1.0.0",
+		Latest:      "v1.1.0",
+		PackageType: "deb",
+		PackageName: "tool",
+		LastError:   "rate limited until 14:32",
+	}}
+
+	var buf bytes.Buffer
+	if err := writeReport(&buf, outputJSON, apps); err != nil {
+		t.Fatalf("writeReport returned error: %v", err)
+	}
+
+	var doc struct {
+		SchemaVersion int              `json:"schema_version"`
+		Apps          []map[string]any `json:"apps"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	if doc.SchemaVersion != reportSchemaVersion || len(doc.Apps) != 1 {
+		t.Fatalf("unexpected document: %s", buf.String())
+	}
+
+	app := doc.Apps[0]
+	for _, key := range []string{"name", "repo_url", "channel", "version", "latest", "last_checked", "hold_version", "package_type", "package_name", "status", "last_error"} {
+		if _, ok := app[key]; !ok {
+			t.Errorf("missing key %q", key)
+		}
+	}
+	status := app["status"].(map[string]any)
+	if status["state"] != "update available" || status["installed"] != true || status["outdated"] != true || status["pinned"] != false {
+		t.Errorf("unexpected status: %v", status)
+	}
+	if app["channel"] != "stable" || app["last_error"] != "rate limited until 14:32" {
+		t.Errorf("unexpected app: %v", app)
+	}
+}
@@ -1,8 +1,6 @@
 package config
 
 import (
-	"encoding/json"
-	"os"
 	"path/filepath"
 )
 
@@ -12,18 +10,71 @@ type App struct {
 	Version     string `json:"version"` // Installed version
 	Latest      string `json:"latest"`  // Latest version detected
 	LastChecked string `json:"last_checked"`
+	// Channel selects which releases are followed: "stable" (default),
+	// "prerelease", or a regular expression matched against the tag name.
+	Channel string `json:"channel,omitempty"`
+	// Pinned apps are held: update checks and installs skip them unless forced.
+	Pinned bool `json:"pinned,omitempty"`
+	// HoldVersion is the version a pinned app is held at.
+	HoldVersion string `json:"hold_version,omitempty"`
+	// PackageName and PackageType record how the app was found on the
+	// system, so it can be removed through the same package manager.
+	PackageName string `json:"package_name,omitempty"`
+	PackageType string `json:"package_type,omitempty"`
+	// InstalledTag, InstalledAsset and InstalledSHA256 record the release
+	// asset autonomix last installed for the app, which the lockfile pins.
+	InstalledTag    string `json:"installed_tag,omitempty"`
+	InstalledAsset  string `json:"installed_asset,omitempty"`
+	InstalledSHA256 string `json:"installed_sha256,omitempty"`
+	// PreferredType is the package type installed when a release offers
+	// several, e.g. "flatpak" over the distro format. It overrides
+	// Config.PreferredType.
+	PreferredType string `json:"preferred_type,omitempty"`
+	// AssetInclude and AssetExclude filter the release assets offered for the
+	// app, e.g. to pick "*-musl.tar.gz" over glibc builds. Each entry is a
+	// glob, or a regular expression between slashes: "/-(gnu|static)/".
+	AssetInclude []string `json:"asset_include,omitempty"`
+	AssetExclude []string `json:"asset_exclude,omitempty"`
+	// AssetPattern remembers the asset picked for an app with asset rules,
+	// with the version replaced by "*". Later updates use the matching asset.
+	AssetPattern string `json:"asset_pattern,omitempty"`
+	// SnapClassic installs the app's snap with classic confinement.
+	SnapClassic bool `json:"snap_classic,omitempty"`
+	// BinaryGlob selects the executables installed from a tarball or zip,
+	// e.g. "*/bin/tool". By default they are found by name.
+	BinaryGlob string `json:"binary_glob,omitempty"`
+	// BinDir overrides Config.BinDir for this app.
+	BinDir string `json:"bin_dir,omitempty"`
+	// LastError is the error from the most recent check or update, if any.
+	LastError string `json:"last_error,omitempty"`
+	// SignaturePolicy is "warn" (default), "require" or "off".
+	SignaturePolicy string `json:"signature_policy,omitempty"`
+	// PublicKeys are the keys trusted to sign this app's release assets.
+	PublicKeys []PublicKey `json:"public_keys,omitempty"`
 }
 
-type Config struct {
-	Apps []App `json:"apps"`
+// PublicKey is a signing key pinned for an app.
+type PublicKey struct {
+	// Type is "minisign", "cosign" or "gpg".
+	Type string `json:"type"`
+	// Key is the public key: a minisign key line, a PEM block or an
+	// ASCII-armored OpenPGP key.
+	Key string `json:"key"`
 }
 
-func GetConfigDir() (string, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
-	}
-	return filepath.Join(home, ".autonomix"), nil
+type Config struct {
+	// SchemaVersion is the version of the file format, see migrations.
+	SchemaVersion int   `json:"schema_version"`
+	Apps          []App `json:"apps"`
+	// GitHubToken is used for API and download requests when neither
+	// GITHUB_TOKEN nor GH_TOKEN is set.
+	GitHubToken string `json:"github_token,omitempty"`
+	// PreferredType is the package type installed when a release offers
+	// several and the app has no preference of its own.
+	PreferredType string `json:"preferred_type,omitempty"`
+	// BinDir is where executables from archives, raw binaries and AppImages
+	// are installed. Defaults to ~/.local/bin.
+	BinDir string `json:"bin_dir,omitempty"`
 }
 
 func GetConfigPath() (string, error) {
@@ -34,47 +85,22 @@ func GetConfigPath() (string, error) {
 	return filepath.Join(dir, "config.json"), nil
 }
 
+// Load reads the config file. A file from an older version of autonomix is
+// upgraded and saved, keeping a backup of the original. An unreadable file
+// is moved aside: Load then returns an empty config and a *QuarantineError.
 func Load() (*Config, error) {
 	path, err := GetConfigPath()
 	if err != nil {
 		return nil, err
 	}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		return &Config{Apps: []App{}}, nil
-	}
-
-	data, err := os.ReadFile(path)
-	if err != nil {
+	if err := migrateLegacyDir(path); err != nil {
 		return nil, err
 	}
-
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, err
+	cfg, from, err := readFile(path)
+	if err != nil || from == SchemaVersion {
+		return cfg, err
 	}
-	return &cfg, nil
-}
-
-func Save(cfg *Config) error {
-	dir, err := GetConfigDir()
-	if err != nil {
-		return err
-	}
-
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return err
-	}
-
-	path, err := GetConfigPath()
-	if err != nil {
-		return err
-	}
-
-	data, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		return err
-	}
-
-	return os.WriteFile(path, data, 0644)
+	// Save the upgrade under the lock, like any other change
+	return Update(func(*Config) error { return nil })
 }
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// HomeEnv relocates every directory autonomix writes to below one root,
+// e.g. for tests and CI runners: config/, cache/, state/, data/ and bin/.
+const HomeEnv = "AUTONOMIX_HOME"
+
+// baseDir returns the autonomix directory of one kind: sub below
+// AUTONOMIX_HOME if set, otherwise autonomix below the XDG base directory in
+// xdgEnv, which defaults to fallback in the home directory.
+func baseDir(sub, xdgEnv string, fallback ...string) (string, error) {
+	if root := os.Getenv(HomeEnv); root != "" {
+		return filepath.Join(root, sub), nil
+	}
+	home, err := xdgHome(xdgEnv, fallback...)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "autonomix"), nil
+}
+
+// xdgHome returns the XDG base directory in env, defaulting to fallback in
+// the home directory. Relative values are ignored, as the spec requires.
+func xdgHome(env string, fallback ...string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home}, fallback...)...), nil
+}
+
+// GetConfigDir returns where config.json lives: $XDG_CONFIG_HOME/autonomix.
+func GetConfigDir() (string, error) {
+	return baseDir("config", "XDG_CONFIG_HOME", ".config")
+}
+
+// GetCacheDir returns where cached API responses and downloads are kept:
+// $XDG_CACHE_HOME/autonomix. Everything in it can be deleted.
+func GetCacheDir() (string, error) {
+	return baseDir("cache", "XDG_CACHE_HOME", ".cache")
+}
+
+// GetStateDir returns where bookkeeping such as the records of user-level
+// installs is kept: $XDG_STATE_HOME/autonomix.
+func GetStateDir() (string, error) {
+	return baseDir("state", "XDG_STATE_HOME", ".local", "state")
+}
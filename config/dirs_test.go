@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(HomeEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg-config"))
+	t.Setenv("XDG_CACHE_HOME", "relative/is/ignored")
+	t.Setenv("XDG_STATE_HOME", "")
+
+	for name, tt := range map[string]struct {
+		get  func() (string, error)
+		want string
+	}{
+		"config": {GetConfigDir, filepath.Join(home, "xdg-config", "autonomix")},
+		"cache":  {GetCacheDir, filepath.Join(home, ".cache", "autonomix")},
+		"state":  {GetStateDir, filepath.Join(home, ".local", "state", "autonomix")},
+	} {
+		if got, err := tt.get(); err != nil || got != tt.want {
+			t.Errorf("%s dir = %q, %v; want %q", name, got, err, tt.want)
+		}
+	}
+
+	root := t.TempDir()
+	t.Setenv(HomeEnv, root)
+	if got, _ := GetCacheDir(); got != filepath.Join(root, "cache") {
+		t.Errorf("cache dir with %s = %q, want it below %s", HomeEnv, got, root)
+	}
+}
+
+func TestLoad_MovesLegacyDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(HomeEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+
+	legacy := filepath.Join(home, ".autonomix")
+	os.MkdirAll(filepath.Join(legacy, "cache", "http"), 0755)
+	os.WriteFile(filepath.Join(legacy, "cache", "http", "entry.json"), []byte("{}"), 0644)
+	os.WriteFile(filepath.Join(legacy, "config.json"), []byte(`{"schema_version": 1, "apps": [{"name": "tool"}]}`), 0644)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Apps) != 1 || cfg.Apps[0].Name != "tool" {
+		t.Errorf("Load() apps = %+v, want the legacy app", cfg.Apps)
+	}
+	if _, err := os.Stat(filepath.Join(home, "config", "autonomix", "config.json")); err != nil {
+		t.Errorf("config was not moved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "cache", "autonomix", "http", "entry.json")); err != nil {
+		t.Errorf("HTTP cache was not moved: %v", err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Error("legacy directory was left behind")
+	}
+}
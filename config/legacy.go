@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// legacyDir is where autonomix kept its config and caches before they were
+// split into the XDG directories.
+func legacyDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".autonomix"), nil
+}
+
+// migrateLegacyDir moves the config, its backups and the caches from
+// ~/.autonomix into the XDG directories, unless a config already exists
+// there. The old directory is removed once empty. With AUTONOMIX_HOME set
+// nothing is moved.
+func migrateLegacyDir(configPath string) error {
+	if os.Getenv(HomeEnv) != "" {
+		return nil
+	}
+	legacy, err := legacyDir()
+	if err != nil {
+		return nil
+	}
+	legacyConfig := filepath.Join(legacy, "config.json")
+	if _, err := os.Stat(legacyConfig); err != nil {
+		return nil
+	}
+	if _, err := os.Stat(configPath); err == nil {
+		return nil
+	}
+	cacheDir, err := GetCacheDir()
+	if err != nil {
+		return err
+	}
+	configDir := filepath.Dir(configPath)
+
+	// Caches can always be rebuilt, so they are dropped if they cannot move
+	for _, name := range []string{"http", "downloads"} {
+		from := filepath.Join(legacy, "cache", name)
+		if _, err := os.Stat(from); err != nil {
+			continue
+		}
+		if err := moveAside(from, filepath.Join(cacheDir, name)); err != nil {
+			os.RemoveAll(from)
+		}
+	}
+	os.Remove(filepath.Join(legacy, "cache"))
+
+	entries, err := os.ReadDir(legacy)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		name := e.Name()
+		// config.json goes last: while it is in the old place the migration
+		// is retried
+		if !strings.HasPrefix(name, "config.json.") || name == "config.json.lock" {
+			continue
+		}
+		if err := moveFile(filepath.Join(legacy, name), filepath.Join(configDir, name)); err != nil {
+			return fmt.Errorf("moving %s to %s: %w", name, configDir, err)
+		}
+	}
+	if err := moveFile(legacyConfig, configPath); err != nil {
+		return fmt.Errorf("moving %s to %s: %w", legacyConfig, configPath, err)
+	}
+	os.Remove(filepath.Join(legacy, "config.json.lock"))
+	os.Remove(legacy)
+	return nil
+}
+
+// moveAside renames from to to, creating the parent of to.
+func moveAside(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0755); err != nil {
+		return err
+	}
+	return os.Rename(from, to)
+}
+
+// moveFile moves a regular file, copying it when a rename is impossible,
+// e.g. across file systems. A file another process moved already is fine.
+func moveFile(from, to string) error {
+	err := moveAside(from, to)
+	if err == nil || os.IsNotExist(err) {
+		return nil
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
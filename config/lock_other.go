@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || freebsd || openbsd || netbsd || dragonfly)
+
+package config
+
+// lockFile is a no-op where flock is unavailable; writes stay atomic but
+// concurrent updates are not serialized.
+func lockFile(path string) (func(), error) {
+	return func() {}, nil
+}
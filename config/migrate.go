@@ -0,0 +1,145 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SchemaVersion is the version of the config format this build writes.
+// Bump it together with a new entry in migrations.
+const SchemaVersion = 1
+
+// migrations upgrade the decoded JSON of a config file one version at a
+// time: migrations[i] turns schema version i into i+1. Working on the raw
+// JSON lets a migration rename or reshape fields the Config struct no longer
+// has.
+var migrations = []func(raw map[string]any) error{
+	// 0 → 1: files from before schema_version, which could hold "apps": null
+	func(raw map[string]any) error {
+		if raw["apps"] == nil {
+			raw["apps"] = []any{}
+		}
+		return nil
+	},
+}
+
+// QuarantineError is returned with an empty config when the config file
+// could not be parsed. The file has been moved aside so autonomix can start.
+type QuarantineError struct {
+	Path string
+	// Moved is where the unreadable file now is.
+	Moved string
+	Err   error
+}
+
+func (e *QuarantineError) Error() string {
+	return fmt.Sprintf("%s could not be read (%v) and was moved to %s. Autonomix started with an empty config; "+
+		"fix the moved file and copy it back to %s to restore your apps", e.Path, e.Err, e.Moved, e.Path)
+}
+
+func (e *QuarantineError) Unwrap() error { return e.Err }
+
+// readFile loads the config at path, upgrading older schema versions in
+// memory. It returns the schema version the file was written with; the
+// caller saves the upgrade. A missing file is an empty config.
+func readFile(path string) (*Config, int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &Config{SchemaVersion: SchemaVersion, Apps: []App{}}, SchemaVersion, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	cfg, from, err := migrate(data)
+	if err != nil {
+		var newer *newerSchemaError
+		if errors.As(err, &newer) {
+			return nil, 0, err
+		}
+		return quarantine(path, err)
+	}
+	return cfg, from, nil
+}
+
+type newerSchemaError struct{ version int }
+
+func (e *newerSchemaError) Error() string {
+	return fmt.Sprintf("config schema version %d is newer than this autonomix supports (%d), please upgrade autonomix", e.version, SchemaVersion)
+}
+
+// migrate decodes a config file and runs the migrations it needs.
+func migrate(data []byte) (*Config, int, error) {
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, 0, err
+	}
+	if raw == nil {
+		return nil, 0, fmt.Errorf("config is not a JSON object")
+	}
+
+	from := 0
+	if v, ok := raw["schema_version"]; ok {
+		n, ok := v.(float64)
+		if !ok || n < 0 || n != float64(int(n)) {
+			return nil, 0, fmt.Errorf("invalid schema_version %v", v)
+		}
+		from = int(n)
+	}
+	if from > SchemaVersion {
+		return nil, 0, &newerSchemaError{version: from}
+	}
+	for v := from; v < SchemaVersion; v++ {
+		if err := migrations[v](raw); err != nil {
+			return nil, 0, fmt.Errorf("migrating config to schema version %d: %w", v+1, err)
+		}
+	}
+	raw["schema_version"] = SchemaVersion
+
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, 0, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(upgraded, &cfg); err != nil {
+		return nil, 0, err
+	}
+	return &cfg, from, nil
+}
+
+// quarantine moves an unreadable config aside and returns an empty one.
+func quarantine(path string, cause error) (*Config, int, error) {
+	moved := path + ".corrupt-" + time.Now().Format("20060102-150405")
+	if err := os.Rename(path, moved); err != nil && !os.IsNotExist(err) {
+		return nil, 0, fmt.Errorf("%s could not be read (%v) or moved aside: %w", path, cause, err)
+	}
+	cfg := &Config{SchemaVersion: SchemaVersion, Apps: []App{}}
+	return cfg, SchemaVersion, &QuarantineError{Path: path, Moved: moved, Err: cause}
+}
+
+// backup copies the config file written with schema version from before it
+// is rewritten. An existing backup of that version is kept.
+func backup(path string, from int) error {
+	dst := fmt.Sprintf("%s.v%d.bak", path, from)
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes data as the config file of a temporary home.
+func writeConfig(t *testing.T, data string) string {
+	t.Helper()
+	t.Setenv(HomeEnv, t.TempDir())
+	path, _ := GetConfigPath()
+	os.MkdirAll(filepath.Dir(path), 0755)
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMigrationsCoverSchemaVersion(t *testing.T) {
+	if len(migrations) != SchemaVersion {
+		t.Errorf("%d migrations for schema version %d", len(migrations), SchemaVersion)
+	}
+}
+
+func TestLoad_MigratesAndBacksUp(t *testing.T) {
+	original := `{"apps": null, "github_token": ""}`
+	path := writeConfig(t, original)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.SchemaVersion != SchemaVersion || cfg.Apps == nil {
+		t.Errorf("Load() = %+v, want schema %d with empty apps", cfg, SchemaVersion)
+	}
+	if data, _ := os.ReadFile(path + ".v0.bak"); string(data) != original {
+		t.Errorf("backup = %q, want the original file", data)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), `"schema_version": 1`) {
+		t.Errorf("migrated file was not saved: %s", data)
+	}
+}
+
+func TestLoad_QuarantinesCorruptFile(t *testing.T) {
+	path := writeConfig(t, `{"apps": [`)
+
+	cfg, err := Load()
+	var qerr *QuarantineError
+	if !errors.As(err, &qerr) {
+		t.Fatalf("Load() error = %v, want a QuarantineError", err)
+	}
+	if cfg == nil || len(cfg.Apps) != 0 {
+		t.Errorf("Load() config = %+v, want an empty one", cfg)
+	}
+	if data, _ := os.ReadFile(qerr.Moved); string(data) != `{"apps": [` {
+		t.Errorf("quarantined file holds %q", data)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("corrupt config was left in place")
+	}
+	if _, err := Load(); err != nil {
+		t.Errorf("Load() after quarantine error = %v", err)
+	}
+}
+
+func TestLoad_RefusesNewerSchema(t *testing.T) {
+	newer := `{"schema_version": 99, "apps": []}`
+	path := writeConfig(t, newer)
+
+	if _, err := Load(); err == nil || !strings.Contains(err.Error(), "upgrade autonomix") {
+		t.Errorf("Load() error = %v, want a request to upgrade", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newer {
+		t.Error("config from a newer version was modified")
+	}
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Update is the only way the config file is written. It locks the file
+// against other autonomix processes, loads the current contents, applies fn
+// and saves the result atomically. Changing the fresh copy instead of saving
+// an old one keeps apps another process added meanwhile, e.g. "add" while
+// the TUI is open. If fn returns an error nothing is written.
+func Update(fn func(cfg *Config) error) (*Config, error) {
+	path, err := GetConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	unlock, err := lockFile(path + ".lock")
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := migrateLegacyDir(path); err != nil {
+		return nil, err
+	}
+	// An unreadable file has been moved aside; the change starts afresh
+	cfg, from, err := readFile(path)
+	var qerr *QuarantineError
+	if err != nil && !errors.As(err, &qerr) {
+		return nil, err
+	}
+	if from < SchemaVersion {
+		if err := backup(path, from); err != nil {
+			return nil, fmt.Errorf("backing up config before migration: %w", err)
+		}
+	}
+	if err := fn(cfg); err != nil {
+		return nil, err
+	}
+	if err := writeFile(path, cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// writeFile replaces path with cfg through a temporary file and a rename,
+// so readers never see a partly written config.
+func writeFile(path string, cfg *Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The config may hold a GitHub token, keep it private in that case
+	perm := os.FileMode(0644)
+	if cfg.GitHubToken != "" {
+		perm = 0600
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
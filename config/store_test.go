@@ -0,0 +1,71 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+)
+
+func TestUpdate_ConcurrentWritersKeepAllApps(t *testing.T) {
+	t.Setenv(HomeEnv, t.TempDir())
+
+	const writers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := Update(func(cfg *Config) error {
+				cfg.Apps = append(cfg.Apps, App{Name: fmt.Sprintf("app%d", i)})
+				return nil
+			})
+			if err != nil {
+				t.Errorf("Update() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Apps) != writers {
+		t.Errorf("config has %d apps, want %d", len(cfg.Apps), writers)
+	}
+}
+
+func TestUpdate_FailedChangeIsNotSaved(t *testing.T) {
+	t.Setenv(HomeEnv, t.TempDir())
+	if _, err := Update(func(cfg *Config) error {
+		cfg.GitHubToken = "secret"
+		cfg.Apps = append(cfg.Apps, App{Name: "kept"})
+		return nil
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	errStop := errors.New("stop")
+	_, err := Update(func(cfg *Config) error {
+		cfg.Apps = nil
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("Update() error = %v, want %v", err, errStop)
+	}
+
+	cfg, _ := Load()
+	if len(cfg.Apps) != 1 || cfg.Apps[0].Name != "kept" {
+		t.Errorf("apps = %+v, want the saved app only", cfg.Apps)
+	}
+	path, _ := GetConfigPath()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("Stat() error = %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("config with a token has mode %v, want 0600", info.Mode().Perm())
+	}
+}
@@ -0,0 +1,152 @@
+package github
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// TokenSource describes where the GitHub token in use was found.
+type TokenSource string
+
+const (
+	SourceNone        TokenSource = ""
+	SourceEnvGitHub   TokenSource = "GITHUB_TOKEN"
+	SourceEnvGH       TokenSource = "GH_TOKEN"
+	SourceConfig      TokenSource = "config"
+	SourceGHHostsFile TokenSource = "gh hosts.yml"
+	SourceGHCLI       TokenSource = "gh auth token"
+)
+
+// tokenMu guards the config token and the discovered token, which
+// SetConfigToken resets while requests may be looking it up.
+var (
+	tokenMu     sync.Mutex
+	configToken string
+
+	discovered  bool
+	cachedToken string
+	cachedSrc   TokenSource
+)
+
+// SetConfigToken registers the token stored in the autonomix config file.
+// It is consulted after the environment and before the gh CLI.
+func SetConfigToken(token string) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	configToken = strings.TrimSpace(token)
+	discovered = false
+}
+
+// Token returns the GitHub token to authenticate with and where it came from.
+// Lookup order: GITHUB_TOKEN, GH_TOKEN, the config file, gh's hosts.yml,
+// and finally `gh auth token` for gh versions that keep the token in a keyring.
+func Token() (string, TokenSource) {
+	tokenMu.Lock()
+	defer tokenMu.Unlock()
+	if !discovered {
+		cachedToken, cachedSrc = discoverToken()
+		discovered = true
+	}
+	return cachedToken, cachedSrc
+}
+
+// discoverToken looks the token up; tokenMu must be held.
+func discoverToken() (string, TokenSource) {
+	if t := strings.TrimSpace(os.Getenv("GITHUB_TOKEN")); t != "" {
+		return t, SourceEnvGitHub
+	}
+	if t := strings.TrimSpace(os.Getenv("GH_TOKEN")); t != "" {
+		return t, SourceEnvGH
+	}
+	if configToken != "" {
+		return configToken, SourceConfig
+	}
+	if t := tokenFromGHHosts(); t != "" {
+		return t, SourceGHHostsFile
+	}
+	if t := tokenFromGHCLI(); t != "" {
+		return t, SourceGHCLI
+	}
+	return "", SourceNone
+}
+
+func ghConfigDir() string {
+	if dir := os.Getenv("GH_CONFIG_DIR"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "gh")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gh")
+}
+
+// tokenFromGHHosts reads the oauth_token for github.com from gh's hosts.yml.
+// The file is simple enough that a line scanner avoids pulling in a YAML parser.
+func tokenFromGHHosts() string {
+	dir := ghConfigDir()
+	if dir == "" {
+		return ""
+	}
+	f, err := os.Open(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	inGitHub := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		// Top-level keys are host names
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inGitHub = strings.TrimSuffix(trimmed, ":") == "github.com"
+			continue
+		}
+		if !inGitHub {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "oauth_token:") {
+			value := strings.TrimSpace(strings.TrimPrefix(trimmed, "oauth_token:"))
+			return strings.Trim(value, `"'`)
+		}
+	}
+	return ""
+}
+
+func tokenFromGHCLI() string {
+	path, err := exec.LookPath("gh")
+	if err != nil {
+		return ""
+	}
+	out, err := exec.Command(path, "auth", "token", "--hostname", "github.com").Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// AuthError is returned when GitHub rejects the configured token.
+type AuthError struct {
+	Source     TokenSource
+	StatusCode int
+}
+
+func (e *AuthError) Error() string {
+	if e.Source == SourceNone {
+		return fmt.Sprintf("github rejected the request (status %d): authentication required, set GITHUB_TOKEN or github_token in the config", e.StatusCode)
+	}
+	return fmt.Sprintf("github token from %s is invalid or expired (status %d): refresh it or unset it to use anonymous access", e.Source, e.StatusCode)
+}
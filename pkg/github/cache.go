@@ -0,0 +1,138 @@
+package github
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tim/autonomix-cli/config"
+)
+
+// CacheTTL is how long a cached response is reused without asking GitHub.
+// Past the TTL the response is revalidated with a conditional request,
+// and a 304 answer does not count against the rate limit.
+var CacheTTL = 5 * time.Minute
+
+// cacheDir is overridden in tests; empty means config.GetCacheDir()/http.
+var cacheDir string
+
+type cacheEntry struct {
+	URL          string          `json:"url"`
+	ETag         string          `json:"etag,omitempty"`
+	LastModified string          `json:"last_modified,omitempty"`
+	FetchedAt    time.Time       `json:"fetched_at"`
+	Body         json.RawMessage `json:"body"`
+}
+
+func cachePath(url string) (string, error) {
+	dir := cacheDir
+	if dir == "" {
+		cacheRoot, err := config.GetCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cacheRoot, "http")
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, hex.EncodeToString(sum[:])+".json"), nil
+}
+
+func loadCacheEntry(url string) *cacheEntry {
+	path, err := cachePath(url)
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil || entry.URL != url {
+		return nil
+	}
+	return &entry
+}
+
+// saveCacheEntry is best effort, a failed write only costs a future request.
+func saveCacheEntry(entry *cacheEntry) {
+	path, err := cachePath(entry.URL)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// getJSON fetches an API URL into v through the on-disk cache.
+// With refresh set the TTL is ignored, but the cached validators are still
+// sent so an unchanged resource costs nothing against the rate limit.
+func getJSON(url string, v any, refresh bool) error {
+	entry := loadCacheEntry(url)
+	if entry != nil && !refresh && now().Sub(entry.FetchedAt) < CacheTTL {
+		return json.Unmarshal(entry.Body, v)
+	}
+
+	req, err := NewRequest(url)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if entry != nil {
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		entry.FetchedAt = now()
+		saveCacheEntry(entry)
+		return json.Unmarshal(entry.Body, v)
+	}
+
+	if err := CheckResponse(resp); err != nil {
+		return err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return err
+	}
+
+	saveCacheEntry(&cacheEntry{
+		URL:          url,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    now(),
+		Body:         body,
+	})
+	return nil
+}
@@ -0,0 +1,103 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+const (
+	// ChannelStable follows /releases/latest, the default.
+	ChannelStable = "stable"
+	// ChannelPrerelease follows the newest published release, pre-releases included.
+	ChannelPrerelease = "prerelease"
+)
+
+// ValidateChannel reports whether channel is "stable", "prerelease" or a valid tag regex.
+func ValidateChannel(channel string) error {
+	_, err := channelMatcher(channel)
+	return err
+}
+
+// channelMatcher returns a predicate for releases on channel,
+// or nil for the stable channel which is served by /releases/latest.
+func channelMatcher(channel string) (func(*Release) bool, error) {
+	switch channel {
+	case "", ChannelStable:
+		return nil, nil
+	case ChannelPrerelease:
+		return func(*Release) bool { return true }, nil
+	}
+	re, err := regexp.Compile(channel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid channel %q: %w", channel, err)
+	}
+	return func(rel *Release) bool { return re.MatchString(rel.TagName) }, nil
+}
+
+// FetchChannelRelease returns the newest release of repoURL on channel.
+// The stable channel is GetLatestRelease; other channels page through
+// /releases, newest first, skipping drafts, and stop at the first match.
+func FetchChannelRelease(repoURL, channel string, refresh bool) (*Release, error) {
+	match, err := channelMatcher(channel)
+	if err != nil {
+		return nil, err
+	}
+	if match == nil {
+		return FetchLatestRelease(repoURL, refresh)
+	}
+
+	var found *Release
+	err = walkReleases(repoURL, refresh, func(rel *Release) bool {
+		if !rel.Draft && match(rel) {
+			found = rel
+			return false
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no release found on channel %q", channel)
+	}
+	return found, nil
+}
+
+// FindChannelRelease returns the highest release of repoURL on channel that
+// accept allows, e.g. one satisfying a version constraint. Unlike
+// FetchChannelRelease it reads every /releases page, skipping drafts, and
+// pre-releases on the stable channel: a backport published after a newer
+// release must not win just because the API lists it first. Releases whose
+// tag holds no version only win when nothing else matches.
+func FindChannelRelease(repoURL, channel string, refresh bool, accept func(*Release) bool) (*Release, error) {
+	match, err := channelMatcher(channel)
+	if err != nil {
+		return nil, err
+	}
+	if match == nil {
+		match = func(rel *Release) bool { return !rel.Prerelease }
+	}
+
+	var found *Release
+	var foundVersion version.Version
+	var foundErr error
+	err = walkReleases(repoURL, refresh, func(rel *Release) bool {
+		if rel.Draft || !match(rel) || !accept(rel) {
+			return true
+		}
+		v, err := version.Parse(rel.TagName)
+		if found == nil || (err == nil && (foundErr != nil || version.Compare(v, foundVersion) > 0)) {
+			found, foundVersion, foundErr = rel, v, err
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no release found on channel %q", channel)
+	}
+	return found, nil
+}
@@ -1,56 +1,131 @@
 package github
 
 import (
-	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strings"
 	"time"
 )
 
+// apiBase is the GitHub REST endpoint, overridden in tests.
+var apiBase = "https://api.github.com"
+
 type Asset struct {
 	Name               string `json:"name"`
 	BrowserDownloadURL string `json:"browser_download_url"`
 	Size               int    `json:"size"`
+	// Digest is "sha256:<hex>" when GitHub has computed one for the asset.
+	Digest string `json:"digest,omitempty"`
 }
 
 type Release struct {
-	TagName string  `json:"tag_name"`
-	Name    string  `json:"name"`
-	Assets  []Asset `json:"assets"`
-	Body    string  `json:"body"`
-	HTMLURL string  `json:"html_url"`
+	TagName     string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	Assets      []Asset   `json:"assets"`
+	Body        string    `json:"body"`
+	HTMLURL     string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
 }
 
 // GetLatestRelease fetches the latest release info for a github repo url
 // url format: https://github.com/owner/repo
 func GetLatestRelease(repoURL string) (*Release, error) {
+	return FetchLatestRelease(repoURL, false)
+}
+
+// FetchLatestRelease is GetLatestRelease with control over the response cache.
+// Set refresh to revalidate with GitHub even if the cached copy is still fresh.
+func FetchLatestRelease(repoURL string, refresh bool) (*Release, error) {
+	repoPath, err := RepoPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/repos/%s/releases/latest", apiBase, repoPath)
+
+	var rel Release
+	if err := getJSON(apiURL, &rel, refresh); err != nil {
+		return nil, err
+	}
+
+	return &rel, nil
+}
+
+// CleanRepoURL reduces a GitHub URL to the bare repository URL, e.g.
+// https://github.com/owner/repo/releases -> https://github.com/owner/repo.
+// Other strings are returned unchanged.
+func CleanRepoURL(repoURL string) string {
+	if strings.Contains(repoURL, "github.com") {
+		parts := strings.Split(repoURL, "github.com/")
+		if len(parts) == 2 {
+			pathParts := strings.Split(strings.Trim(parts[1], "/"), "/")
+			if len(pathParts) >= 2 {
+				return "https://github.com/" + pathParts[0] + "/" + pathParts[1]
+			}
+		}
+	}
+	return repoURL
+}
+
+// RepoPath extracts "owner/repo" from a github repo url.
+func RepoPath(repoURL string) (string, error) {
 	parts := strings.Split(repoURL, "github.com/")
 	if len(parts) < 2 {
-		return nil, fmt.Errorf("invalid github url")
-	}
-	repoPath := strings.TrimSuffix(parts[1], "/")
-	
-	apiURL := fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", repoPath)
-	
-	client := http.Client{
-		Timeout: 10 * time.Second,
+		return "", fmt.Errorf("invalid github url")
 	}
+	return strings.TrimSuffix(parts[1], "/"), nil
+}
 
-	resp, err := client.Get(apiURL)
+// NewRequest builds a GET request carrying the GitHub token, if one is available.
+// It is used for both API calls and release asset downloads.
+func NewRequest(url string) (*http.Request, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	req.Header.Set("User-Agent", "autonomix-cli")
+	if token, _ := Token(); token != "" {
+		// net/http drops this header when redirected to another host,
+		// so asset downloads served from a CDN never see the token.
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("github api returned status: %d", resp.StatusCode)
+// CheckResponse turns a non-200 response into an error.
+func CheckResponse(resp *http.Response) error {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized:
+		_, src := Token()
+		return &AuthError{Source: src, StatusCode: resp.StatusCode}
+	default:
+		return fmt.Errorf("github api returned status: %d", resp.StatusCode)
 	}
+}
 
-	var rel Release
-	if err := json.NewDecoder(resp.Body).Decode(&rel); err != nil {
+// FetchAsset downloads a small release asset, such as a checksum or signature
+// file, into memory. At most maxSize bytes are read.
+func FetchAsset(asset Asset, maxSize int64) ([]byte, error) {
+	req, err := NewRequest(asset.BrowserDownloadURL)
+	if err != nil {
 		return nil, err
 	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
 
-	return &rel, nil
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, CheckResponse(resp)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSize))
 }
@@ -0,0 +1,221 @@
+package github
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+func withTestServer(t *testing.T, handler http.HandlerFunc) {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	oldBase, oldSleep, oldCache := apiBase, sleep, cacheDir
+	apiBase = srv.URL
+	sleep = func(time.Duration) {}
+	cacheDir = t.TempDir()
+	t.Cleanup(func() {
+		apiBase, sleep, cacheDir = oldBase, oldSleep, oldCache
+	})
+	t.Setenv("GITHUB_TOKEN", "test-token")
+	SetConfigToken("")
+}
+
+func TestGetLatestRelease_SendsToken(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-token" {
+			t.Errorf("Authorization = %q, want bearer token", got)
+		}
+		fmt.Fprint(w, `{"tag_name":"v1.2.3"}`)
+	})
+
+	rel, err := GetLatestRelease("https://github.com/owner/repo")
+	if err != nil {
+		t.Fatalf("GetLatestRelease returned error: %v", err)
+	}
+	if rel.TagName != "v1.2.3" {
+		t.Errorf("TagName = %q, want v1.2.3", rel.TagName)
+	}
+}
+
+func TestGetLatestRelease_AuthError(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+
+	_, err := GetLatestRelease("https://github.com/owner/repo")
+	var authErr *AuthError
+	if !errors.As(err, &authErr) {
+		t.Fatalf("expected *AuthError, got %v", err)
+	}
+	if authErr.Source != SourceEnvGitHub {
+		t.Errorf("Source = %q, want %q", authErr.Source, SourceEnvGitHub)
+	}
+}
+
+func TestGetLatestRelease_RateLimited(t *testing.T) {
+	reset := time.Now().Add(30 * time.Minute).Truncate(time.Second)
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "60")
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", fmt.Sprint(reset.Unix()))
+		w.WriteHeader(http.StatusForbidden)
+	})
+
+	_, err := GetLatestRelease("https://github.com/owner/repo")
+	var rlErr *RateLimitError
+	if !errors.As(err, &rlErr) {
+		t.Fatalf("expected *RateLimitError, got %v", err)
+	}
+	if !rlErr.Reset.Equal(reset) {
+		t.Errorf("Reset = %v, want %v", rlErr.Reset, reset)
+	}
+	want := "rate limited until " + reset.Local().Format("15:04")
+	if rlErr.Error() != want {
+		t.Errorf("Error() = %q, want %q", rlErr.Error(), want)
+	}
+}
+
+func TestGetLatestRelease_RetriesTransientFailures(t *testing.T) {
+	calls := 0
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			fmt.Fprint(w, `{"tag_name":"v2.0.0"}`)
+		}
+	})
+
+	rel, err := GetLatestRelease("https://github.com/owner/repo")
+	if err != nil {
+		t.Fatalf("GetLatestRelease returned error: %v", err)
+	}
+	if rel.TagName != "v2.0.0" || calls != 3 {
+		t.Errorf("got tag %q after %d calls, want v2.0.0 after 3", rel.TagName, calls)
+	}
+}
+
+func TestFetchLatestRelease_Cache(t *testing.T) {
+	calls, revalidated := 0, 0
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			revalidated++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		fmt.Fprint(w, `{"tag_name":"v3.0.0"}`)
+	})
+
+	for i, refresh := range []bool{false, false, true} {
+		rel, err := FetchLatestRelease("https://github.com/owner/repo", refresh)
+		if err != nil {
+			t.Fatalf("fetch %d returned error: %v", i, err)
+		}
+		if rel.TagName != "v3.0.0" {
+			t.Errorf("fetch %d: TagName = %q, want v3.0.0", i, rel.TagName)
+		}
+	}
+
+	// The second call is served within the TTL, the refresh revalidates
+	if calls != 2 || revalidated != 1 {
+		t.Errorf("got %d requests (%d revalidated), want 2 (1 revalidated)", calls, revalidated)
+	}
+}
+
+func TestFetchChannelRelease(t *testing.T) {
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"tag_name":"v2.0.0-rc.1","draft":true,"prerelease":true},
+			{"tag_name":"nightly-20240102","prerelease":true},
+			{"tag_name":"v1.9.0-beta.2","prerelease":true},
+			{"tag_name":"v1.8.0"}
+		]`)
+	})
+
+	tests := []struct {
+		channel string
+		want    string
+	}{
+		{ChannelPrerelease, "nightly-20240102"},
+		{`^v\d+\.\d+\.\d+-beta`, "v1.9.0-beta.2"},
+		{`^v\d+\.\d+\.\d+$`, "v1.8.0"},
+	}
+	for _, tt := range tests {
+		rel, err := FetchChannelRelease("https://github.com/owner/repo", tt.channel, false)
+		if err != nil {
+			t.Errorf("channel %q: unexpected error: %v", tt.channel, err)
+			continue
+		}
+		if rel.TagName != tt.want {
+			t.Errorf("channel %q: got %q, want %q", tt.channel, rel.TagName, tt.want)
+		}
+	}
+
+	if _, err := FetchChannelRelease("https://github.com/owner/repo", "^stable-", false); err == nil {
+		t.Error("expected an error for a channel without releases")
+	}
+	if err := ValidateChannel("(["); err == nil {
+		t.Error("expected an error for an invalid channel regex")
+	}
+}
+
+func TestFetchChannelRelease_StopsAtFirstMatch(t *testing.T) {
+	requests := 0
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// A full page, so the walk would go on to the next one
+		page := make([]string, releasesPerPage)
+		for i := range page {
+			page[i] = fmt.Sprintf(`{"tag_name":"v1.%d.0-rc.1","prerelease":true}`, releasesPerPage-i)
+		}
+		fmt.Fprintf(w, "[%s]", strings.Join(page, ","))
+	})
+
+	rel, err := FetchChannelRelease("https://github.com/owner/repo", ChannelPrerelease, false)
+	if err != nil {
+		t.Fatalf("FetchChannelRelease() error = %v", err)
+	}
+	if rel.TagName != fmt.Sprintf("v1.%d.0-rc.1", releasesPerPage) || requests != 1 {
+		t.Errorf("FetchChannelRelease() = %s after %d requests, want the first release after one", rel.TagName, requests)
+	}
+}
+
+func TestFindChannelRelease_PicksHighestMatch(t *testing.T) {
+	// 1.4.9 is a backport published after 1.5.0, so the API lists it first
+	withTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"tag_name":"v1.4.9"},
+			{"tag_name":"v2.0.0"},
+			{"tag_name":"v1.5.0"},
+			{"tag_name":"v1.4.0"}
+		]`)
+	})
+
+	constraint, err := version.ParseConstraint(">=1.4 <2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel, err := FindChannelRelease("https://github.com/owner/repo", ChannelStable, false, func(rel *Release) bool {
+		return constraint.Check(rel.TagName)
+	})
+	if err != nil {
+		t.Fatalf("FindChannelRelease() error = %v", err)
+	}
+	if rel.TagName != "v1.5.0" {
+		t.Errorf("FindChannelRelease() = %s, want v1.5.0", rel.TagName)
+	}
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxAttempts bounds how often a single request is tried.
+	maxAttempts = 3
+	// maxRetryWait is the longest we are willing to block on a Retry-After
+	// before giving up and reporting the rate limit to the caller.
+	maxRetryWait = 10 * time.Second
+)
+
+var (
+	httpClient = &http.Client{Timeout: 10 * time.Second}
+	sleep      = time.Sleep
+	now        = time.Now
+)
+
+// RateLimitError is returned when GitHub refuses a request because the
+// primary or secondary rate limit was exceeded.
+type RateLimitError struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited until %s", e.Reset.Local().Format("15:04"))
+}
+
+// Do sends req, retrying transient network errors, 5xx responses and short
+// rate limit waits with exponential backoff. Rate limits that cannot be
+// waited out are returned as a *RateLimitError.
+func Do(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		last := attempt == maxAttempts
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			if last {
+				return nil, err
+			}
+			sleep(backoff(attempt))
+			continue
+		}
+
+		if resp.StatusCode >= 500 && !last {
+			resp.Body.Close()
+			sleep(backoff(attempt))
+			continue
+		}
+
+		if rlErr := rateLimitFromResponse(resp); rlErr != nil {
+			resp.Body.Close()
+			wait := rlErr.Reset.Sub(now())
+			if last || wait > maxRetryWait {
+				return nil, rlErr
+			}
+			if wait > 0 {
+				sleep(wait)
+			}
+			continue
+		}
+
+		return resp, nil
+	}
+}
+
+func backoff(attempt int) time.Duration {
+	return time.Duration(1<<(attempt-1)) * time.Second
+}
+
+// rateLimitFromResponse inspects the rate limit headers of resp and returns
+// a *RateLimitError if the response is a rate limit rejection.
+func rateLimitFromResponse(resp *http.Response) *RateLimitError {
+	remaining, hasRemaining := headerInt(resp.Header, "X-RateLimit-Remaining")
+	retryAfter := resp.Header.Get("Retry-After")
+
+	limited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && ((hasRemaining && remaining == 0) || retryAfter != ""))
+	if !limited {
+		return nil
+	}
+
+	limit, _ := headerInt(resp.Header, "X-RateLimit-Limit")
+	rlErr := &RateLimitError{Limit: limit, Remaining: remaining}
+
+	switch {
+	case retryAfter != "":
+		rlErr.Reset = parseRetryAfter(retryAfter)
+	case resp.Header.Get("X-RateLimit-Reset") != "":
+		reset, _ := headerInt(resp.Header, "X-RateLimit-Reset")
+		rlErr.Reset = time.Unix(int64(reset), 0)
+	}
+	if rlErr.Reset.IsZero() {
+		// GitHub asks clients to wait at least a minute for secondary limits
+		rlErr.Reset = now().Add(time.Minute)
+	}
+	return rlErr
+}
+
+// parseRetryAfter accepts both forms allowed by RFC 9110: delay seconds and an HTTP date.
+func parseRetryAfter(value string) time.Time {
+	if secs, err := strconv.Atoi(value); err == nil {
+		return now().Add(time.Duration(secs) * time.Second)
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
+
+func headerInt(h http.Header, key string) (int, bool) {
+	v := h.Get(key)
+	if v == "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,71 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	releasesPerPage = 100
+	// maxReleasePages caps how far back release listings page.
+	maxReleasePages = 10
+)
+
+// walkReleases calls fn for each release of repoURL, newest first,
+// until fn returns false or the releases run out.
+func walkReleases(repoURL string, refresh bool, fn func(*Release) bool) error {
+	repoPath, err := RepoPath(repoURL)
+	if err != nil {
+		return err
+	}
+
+	for page := 1; page <= maxReleasePages; page++ {
+		apiURL := fmt.Sprintf("%s/repos/%s/releases?per_page=%d&page=%d", apiBase, repoPath, releasesPerPage, page)
+
+		var releases []Release
+		if err := getJSON(apiURL, &releases, refresh); err != nil {
+			return err
+		}
+		for i := range releases {
+			if !fn(&releases[i]) {
+				return nil
+			}
+		}
+		if len(releases) < releasesPerPage {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ListReleases returns every published release of repoURL, newest first.
+// Drafts are skipped since they cannot be downloaded anonymously.
+func ListReleases(repoURL string, refresh bool) ([]Release, error) {
+	var releases []Release
+	err := walkReleases(repoURL, refresh, func(rel *Release) bool {
+		if !rel.Draft {
+			releases = append(releases, *rel)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+// GetReleaseByTag fetches the release of repoURL tagged tag.
+func GetReleaseByTag(repoURL, tag string) (*Release, error) {
+	repoPath, err := RepoPath(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := fmt.Sprintf("%s/repos/%s/releases/tags/%s", apiBase, repoPath, url.PathEscape(tag))
+
+	var rel Release
+	if err := getJSON(apiURL, &rel, false); err != nil {
+		return nil, err
+	}
+	return &rel, nil
+}
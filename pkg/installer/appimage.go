@@ -0,0 +1,330 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/system"
+)
+
+// extractTimeout bounds how long an AppImage may take to extract its
+// desktop entry and icon.
+const extractTimeout = 30 * time.Second
+
+// IsUserInstall reports whether the package at path is installed into the
+// user's home directory by autonomix itself instead of by a package manager.
+func IsUserInstall(path string) bool {
+	switch packages.DetectType(path) {
+	case packages.AppImage, packages.Archive, packages.RawBinary:
+		return true
+	}
+	return false
+}
+
+// InstallUserPackage installs a package for which IsUserInstall is true.
+func InstallUserPackage(pkg Package, appName, version string) (system.LocalInstall, error) {
+	switch t := packages.DetectType(pkg.Path); t {
+	case packages.AppImage:
+		return InstallAppImage(pkg, appName, version)
+	case packages.Archive, packages.RawBinary:
+		return InstallBinaries(pkg, appName, version)
+	default:
+		return system.LocalInstall{}, fmt.Errorf("%s is not a user-level package", t)
+	}
+}
+
+// userBinDir returns the bin directory for pkg: its own BinDir if set,
+// otherwise system.BinDir.
+func userBinDir(pkg Package) (string, error) {
+	if pkg.BinDir == "" {
+		return system.BinDir()
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return system.ExpandHome(pkg.BinDir, home), nil
+}
+
+// InstallAppImage installs the AppImage pkg as appName: it is copied to
+// ~/.local/share/autonomix/appimages, made executable and linked into the
+// bin directory (~/.local/bin by default). Its desktop entry and icon are
+// extracted into the XDG data directory when the AppImage provides them. An
+// existing install is replaced atomically, so the app is never missing or
+// half-written.
+func InstallAppImage(pkg Package, appName, version string) (system.LocalInstall, error) {
+	path := pkg.Path
+	name := system.LocalName(appName)
+	localDir, err := system.LocalDir()
+	if err != nil {
+		return system.LocalInstall{}, err
+	}
+	binDir, err := userBinDir(pkg)
+	if err != nil {
+		return system.LocalInstall{}, err
+	}
+
+	prev, hadPrev := system.LoadLocalInstall(name)
+	target := filepath.Join(localDir, "appimages", name+".AppImage")
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return system.LocalInstall{}, err
+	}
+	if err := copyFileAtomic(path, target, 0755); err != nil {
+		return system.LocalInstall{}, fmt.Errorf("installing %s: %w", filepath.Base(path), err)
+	}
+
+	link := filepath.Join(binDir, name)
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return system.LocalInstall{}, err
+	}
+	if err := symlinkAtomic(target, link); err != nil {
+		return system.LocalInstall{}, err
+	}
+
+	rec := system.LocalInstall{
+		Name:    name,
+		Version: version,
+		Type:    packages.AppImage,
+		Path:    target,
+		Files:   []string{target, link},
+	}
+	// Desktop integration is best effort: not every AppImage ships an entry
+	rec.Files = append(rec.Files, integrateDesktop(target, name)...)
+
+	if err := system.SaveLocalInstall(rec); err != nil {
+		return rec, err
+	}
+	if hadPrev {
+		removeStale(prev.Files, rec.Files)
+	}
+	return rec, nil
+}
+
+// removeStale deletes the files of a previous install that the new one no
+// longer provides, e.g. an icon that changed format.
+func removeStale(old, current []string) {
+	keep := make(map[string]bool)
+	for _, f := range current {
+		keep[f] = true
+	}
+	for _, f := range old {
+		if !keep[f] {
+			os.Remove(f)
+		}
+	}
+}
+
+// copyFileAtomic copies src to a temporary file next to dst and renames it
+// into place.
+func copyFileAtomic(src, dst string, perm os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".tmp"
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	// The umask may have narrowed perm
+	if err := os.Chmod(tmp, perm); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// symlinkAtomic points link at target, replacing an existing symlink but
+// never a regular file that autonomix did not create.
+func symlinkAtomic(target, link string) error {
+	if fi, err := os.Lstat(link); err == nil && fi.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s already exists and is not a symlink", link)
+	}
+	tmp := link + ".tmp"
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return err
+	}
+	return os.Rename(tmp, link)
+}
+
+// integrateDesktop extracts the desktop entry and icon of the AppImage at
+// appImage and installs them under the XDG data directory. It returns the
+// files it created.
+func integrateDesktop(appImage, name string) []string {
+	dataHome, err := system.DataHome()
+	if err != nil {
+		return nil
+	}
+	tmp, err := os.MkdirTemp("", "autonomix-appimage-*")
+	if err != nil {
+		return nil
+	}
+	defer os.RemoveAll(tmp)
+
+	// Type 2 AppImages extract matching files into ./squashfs-root
+	for _, pattern := range []string{"*.desktop", ".DirIcon", "*.png", "*.svg"} {
+		ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+		cmd := exec.CommandContext(ctx, appImage, "--appimage-extract", pattern)
+		cmd.Dir = tmp
+		cmd.Run()
+		cancel()
+	}
+	root := filepath.Join(tmp, "squashfs-root")
+
+	entries, _ := filepath.Glob(filepath.Join(root, "*.desktop"))
+	if len(entries) == 0 {
+		return nil
+	}
+	data, err := os.ReadFile(entries[0])
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	iconName := "autonomix-" + name
+	icon := ""
+	if src, ext := findIcon(root, desktopValue(data, "Icon")); src != "" {
+		size := "256x256"
+		if ext == ".svg" {
+			size = "scalable"
+		}
+		iconPath := filepath.Join(dataHome, "icons", "hicolor", size, "apps", iconName+ext)
+		if os.MkdirAll(filepath.Dir(iconPath), 0755) == nil && copyFileAtomic(src, iconPath, 0644) == nil {
+			files = append(files, iconPath)
+			icon = iconName
+		}
+	}
+
+	entry := filepath.Join(dataHome, "applications", iconName+".desktop")
+	if err := os.MkdirAll(filepath.Dir(entry), 0755); err != nil {
+		return files
+	}
+	tmpEntry := filepath.Join(tmp, "entry.desktop")
+	if err := os.WriteFile(tmpEntry, RewriteDesktopEntry(data, appImage, icon), 0644); err != nil {
+		return files
+	}
+	if copyFileAtomic(tmpEntry, entry, 0644) == nil {
+		files = append(files, entry)
+	}
+	return files
+}
+
+// findIcon returns the icon file named icon in the AppImage root, falling
+// back to .DirIcon.
+func findIcon(root, icon string) (string, string) {
+	if icon != "" && !strings.Contains(icon, "/") {
+		for _, ext := range []string{".svg", ".png"} {
+			path := filepath.Join(root, icon+ext)
+			if fi, err := os.Stat(path); err == nil && fi.Mode().IsRegular() {
+				return path, ext
+			}
+		}
+	}
+	path := filepath.Join(root, ".DirIcon")
+	f, err := os.Open(path)
+	if err != nil {
+		return "", ""
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := f.Read(head)
+	if bytes.HasPrefix(head[:n], []byte("\x89PNG")) {
+		return path, ".png"
+	}
+	if bytes.Contains(head[:n], []byte("<svg")) {
+		return path, ".svg"
+	}
+	return "", ""
+}
+
+// desktopValue returns the value of key in the [Desktop Entry] group.
+func desktopValue(data []byte, key string) string {
+	var group string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			group = line
+			continue
+		}
+		if group != "[Desktop Entry]" {
+			continue
+		}
+		if k, v, ok := strings.Cut(line, "="); ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v)
+		}
+	}
+	return ""
+}
+
+// RewriteDesktopEntry points the Exec lines of a desktop entry at the
+// installed AppImage and, unless icon is empty, its Icon at the extracted
+// icon. TryExec is dropped, since it names the program inside the AppImage.
+func RewriteDesktopEntry(data []byte, appImage, icon string) []byte {
+	execPath := appImage
+	if strings.ContainsAny(execPath, " \t\"'\\$`") {
+		execPath = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(execPath) + `"`
+	}
+
+	var out bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		key, value, _ := strings.Cut(line, "=")
+		switch strings.TrimSpace(key) {
+		case "Exec":
+			// Keep the field codes, e.g. "AppRun %U" -> "<appimage> %U"
+			line = "Exec=" + execPath + execArgs(value)
+		case "Icon":
+			if icon != "" {
+				line = "Icon=" + icon
+			}
+		case "TryExec":
+			continue
+		}
+		out.WriteString(line + "\n")
+	}
+	return out.Bytes()
+}
+
+// execArgs returns what follows the program in an Exec value, with its
+// leading space. The program may be quoted.
+func execArgs(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, `"`) {
+		for i := 1; i < len(value); i++ {
+			switch value[i] {
+			case '\\':
+				i++
+			case '"':
+				return value[i+1:]
+			}
+		}
+		return ""
+	}
+	if i := strings.IndexByte(value, ' '); i >= 0 {
+		return value[i:]
+	}
+	return ""
+}
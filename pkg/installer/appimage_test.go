@@ -0,0 +1,124 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/system"
+)
+
+// fakeAppImage is a script answering --appimage-extract like a type 2
+// AppImage, with a desktop entry and an SVG icon.
+const fakeAppImage = `#!/bin/sh
+if [ "$1" = "--appimage-extract" ]; then
+	mkdir -p squashfs-root
+	case "$2" in
+	"*.desktop")
+		printf '[Desktop Entry]\nName=My App\nExec=AppRun %%U\nTryExec=myapp\nIcon=myapp\n' > squashfs-root/myapp.desktop ;;
+	"*.svg")
+		printf '<svg xmlns="http://www.w3.org/2000/svg"/>\n' > squashfs-root/myapp.svg ;;
+	esac
+	exit 0
+fi
+echo "%s"
+`
+
+func writeFakeAppImage(t *testing.T, dir, version string) string {
+	t.Helper()
+	path := filepath.Join(dir, "MyApp-"+version+"-x86_64.AppImage")
+	script := strings.Replace(fakeAppImage, "%s", version, 1)
+	if err := os.WriteFile(path, []byte(script), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestInstallAppImage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	downloads := t.TempDir()
+
+	rec, err := InstallUserPackage(Package{Path: writeFakeAppImage(t, downloads, "1.0.0")}, "My App", "v1.0.0")
+	if err != nil {
+		t.Fatalf("InstallUserPackage() error = %v", err)
+	}
+
+	target := filepath.Join(home, "data", "autonomix", "appimages", "my-app.AppImage")
+	if rec.Path != target {
+		t.Errorf("installed at %s, want %s", rec.Path, target)
+	}
+	if fi, err := os.Stat(target); err != nil || fi.Mode().Perm()&0100 == 0 {
+		t.Errorf("AppImage is not installed as an executable: %v", err)
+	}
+	if dest, err := os.Readlink(filepath.Join(home, ".local", "bin", "my-app")); err != nil || dest != target {
+		t.Errorf("~/.local/bin/my-app -> %q, %v; want %s", dest, err, target)
+	}
+
+	entry, err := os.ReadFile(filepath.Join(home, "data", "applications", "autonomix-my-app.desktop"))
+	if err != nil {
+		t.Fatalf("desktop entry not installed: %v", err)
+	}
+	for _, want := range []string{"Exec=" + target + " %U", "Icon=autonomix-my-app"} {
+		if !strings.Contains(string(entry), want) {
+			t.Errorf("desktop entry lacks %q:\n%s", want, entry)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(home, "data", "icons", "hicolor", "scalable", "apps", "autonomix-my-app.svg")); err != nil {
+		t.Errorf("icon not installed: %v", err)
+	}
+
+	// An update replaces the AppImage and the recorded version
+	if _, err := InstallUserPackage(Package{Path: writeFakeAppImage(t, downloads, "1.1.0")}, "My App", "v1.1.0"); err != nil {
+		t.Fatalf("updating: %v", err)
+	}
+	got, ok := system.LoadLocalInstall("My App")
+	if !ok || got.Version != "v1.1.0" || got.Type != packages.AppImage {
+		t.Errorf("LoadLocalInstall() = %+v, %v; want v1.1.0 AppImage", got, ok)
+	}
+	if data, _ := os.ReadFile(target); !strings.Contains(string(data), "1.1.0") {
+		t.Error("AppImage was not replaced by the update")
+	}
+
+	cmd, err := GetUninstallCmd(packages.AppImage, got.Name)
+	if err != nil {
+		t.Fatalf("GetUninstallCmd() error = %v", err)
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if _, ok := system.LoadLocalInstall("My App"); ok {
+		t.Error("AppImage is still reported as installed after uninstalling")
+	}
+}
+
+func TestInstallAppImage_KeepsForeignBinary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	bin := filepath.Join(home, ".local", "bin", "my-app")
+	os.MkdirAll(filepath.Dir(bin), 0755)
+	os.WriteFile(bin, []byte("not ours"), 0755)
+
+	if _, err := InstallAppImage(Package{Path: writeFakeAppImage(t, t.TempDir(), "1.0.0")}, "My App", "v1.0.0"); err == nil {
+		t.Fatal("InstallAppImage() replaced a binary it did not create")
+	}
+	if data, _ := os.ReadFile(bin); string(data) != "not ours" {
+		t.Error("existing binary was modified")
+	}
+}
+
+func TestRewriteDesktopEntry(t *testing.T) {
+	in := "[Desktop Entry]\nName=App\nExec=\"/tmp/.mount_x/App Run\" --flag %F\nTryExec=app\nIcon=app\n\n[Desktop Action New]\nExec=AppRun --new\n"
+	got := string(RewriteDesktopEntry([]byte(in), "/home/u/My Apps/app.AppImage", "autonomix-app"))
+	want := "[Desktop Entry]\nName=App\nExec=\"/home/u/My Apps/app.AppImage\" --flag %F\nIcon=autonomix-app\n\n[Desktop Action New]\nExec=\"/home/u/My Apps/app.AppImage\" --new\n"
+	if got != want {
+		t.Errorf("RewriteDesktopEntry() =\n%s\nwant\n%s", got, want)
+	}
+}
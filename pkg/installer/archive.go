@@ -0,0 +1,313 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/system"
+)
+
+// elfMagic starts every Linux executable.
+var elfMagic = []byte("\x7fELF")
+
+// InstallBinaries installs the executables of an Archive or RawBinary pkg
+// into the bin directory. Archives are extracted and searched for
+// executables matching pkg.BinaryGlob, or by name heuristics when it is
+// empty; a raw binary is installed as LocalName(appName). The files placed
+// are recorded, so later installs and uninstalls only touch those.
+func InstallBinaries(pkg Package, appName, version string) (system.LocalInstall, error) {
+	name := system.LocalName(appName)
+	t := packages.DetectType(pkg.Path)
+	binDir, err := userBinDir(pkg)
+	if err != nil {
+		return system.LocalInstall{}, err
+	}
+
+	// bins maps the installed name to the file providing it
+	bins := make(map[string]string)
+	switch t {
+	case packages.RawBinary:
+		bins[name] = pkg.Path
+	case packages.Archive:
+		tmp, err := os.MkdirTemp("", "autonomix-archive-*")
+		if err != nil {
+			return system.LocalInstall{}, err
+		}
+		defer os.RemoveAll(tmp)
+		if err := ExtractArchive(pkg.Path, tmp); err != nil {
+			return system.LocalInstall{}, fmt.Errorf("extracting %s: %w", filepath.Base(pkg.Path), err)
+		}
+		found, err := FindBinaries(tmp, pkg.BinaryGlob, name)
+		if err != nil {
+			return system.LocalInstall{}, fmt.Errorf("%s: %w", filepath.Base(pkg.Path), err)
+		}
+		for _, f := range found {
+			bins[filepath.Base(f)] = f
+		}
+	default:
+		return system.LocalInstall{}, fmt.Errorf("%s is not an archive or binary", t)
+	}
+
+	prev, hadPrev := system.LoadLocalInstall(name)
+	owned := make(map[string]bool)
+	if hadPrev {
+		for _, f := range prev.Files {
+			owned[f] = true
+		}
+	}
+	var files []string
+	for base := range bins {
+		target := filepath.Join(binDir, base)
+		if _, err := os.Lstat(target); err == nil && !owned[target] {
+			return system.LocalInstall{}, fmt.Errorf("%s already exists and was not installed by autonomix", target)
+		}
+		files = append(files, target)
+	}
+	sort.Strings(files)
+
+	if err := os.MkdirAll(binDir, 0755); err != nil {
+		return system.LocalInstall{}, err
+	}
+	for _, target := range files {
+		if err := copyFileAtomic(bins[filepath.Base(target)], target, 0755); err != nil {
+			return system.LocalInstall{}, fmt.Errorf("installing %s: %w", target, err)
+		}
+	}
+
+	rec := system.LocalInstall{
+		Name:    name,
+		Version: version,
+		Type:    t,
+		Path:    files[0],
+		Files:   files,
+	}
+	// Prefer the executable named after the app as the one reported
+	if bins[name] != "" {
+		rec.Path = filepath.Join(binDir, name)
+	}
+	if err := system.SaveLocalInstall(rec); err != nil {
+		return rec, err
+	}
+	if hadPrev {
+		removeStale(prev.Files, rec.Files)
+	}
+	return rec, nil
+}
+
+// ExtractArchive unpacks the tarball or zip at src into dir. Only regular
+// files and directories are extracted, and entries that would land outside
+// dir are rejected.
+func ExtractArchive(src, dir string) error {
+	lower := strings.ToLower(src)
+	if strings.HasSuffix(lower, ".zip") {
+		return extractZip(src, dir)
+	}
+	if strings.HasSuffix(lower, ".tar.xz") || strings.HasSuffix(lower, ".txz") {
+		// The standard library has no xz reader; tar does
+		ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+		defer cancel()
+		out, err := exec.CommandContext(ctx, "tar", "-xJf", src, "-C", dir, "--no-same-owner").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("tar: %v: %s", err, bytes.TrimSpace(out))
+		}
+		return checkExtracted(dir)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var r io.Reader = f
+	switch {
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	case strings.HasSuffix(lower, ".tar.bz2"), strings.HasSuffix(lower, ".tbz2"):
+		r = bzip2.NewReader(f)
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		target, err := entryPath(dir, hdr.Name)
+		if err != nil {
+			return err
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(target, tr, hdr.FileInfo().Mode().Perm()); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(src, dir string) error {
+	zr, err := zip.OpenReader(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+	for _, zf := range zr.File {
+		target, err := entryPath(dir, zf.Name)
+		if err != nil {
+			return err
+		}
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			err = writeEntry(target, rc, mode.Perm())
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// entryPath returns where the archive entry name is extracted to in dir.
+func entryPath(dir, name string) (string, error) {
+	if path.IsAbs(name) || strings.Contains("/"+name+"/", "/../") {
+		return "", fmt.Errorf("archive entry %q is outside the archive", name)
+	}
+	clean := path.Clean("/" + name)
+	return filepath.Join(dir, filepath.FromSlash(clean)), nil
+}
+
+func writeEntry(target string, r io.Reader, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm|0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// checkExtracted removes symlinks and other special files that tar may have
+// created, so only regular files and directories remain in dir.
+func checkExtracted(dir string) error {
+	return filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() && !fi.IsDir() {
+			return os.Remove(p)
+		}
+		return nil
+	})
+}
+
+// FindBinaries returns the executables to install from the extracted
+// archive in dir. With a glob, every file whose path relative to dir or
+// base name matches it is returned. Otherwise a single ELF executable is
+// used; among several, the one named after the app wins, else the only one
+// without a "-cli" or "-debug" suffix.
+func FindBinaries(dir, glob, name string) ([]string, error) {
+	var all, elfs []string
+	err := filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		all = append(all, p)
+		if isELF(p) {
+			elfs = append(elfs, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if glob != "" {
+		var matched []string
+		for _, p := range all {
+			rel, _ := filepath.Rel(dir, p)
+			if ok, _ := filepath.Match(glob, filepath.ToSlash(rel)); ok {
+				matched = append(matched, p)
+			} else if ok, _ := filepath.Match(glob, filepath.Base(p)); ok {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("no file matches binary_glob %q", glob)
+		}
+		return matched, nil
+	}
+
+	switch len(elfs) {
+	case 0:
+		return nil, fmt.Errorf("no executable found; set binary_glob for this app")
+	case 1:
+		return elfs, nil
+	}
+	for _, p := range elfs {
+		if filepath.Base(p) == name {
+			return []string{p}, nil
+		}
+	}
+	var plain []string
+	for _, p := range elfs {
+		base := filepath.Base(p)
+		if !strings.HasSuffix(base, "-cli") && !strings.HasSuffix(base, "-debug") {
+			plain = append(plain, p)
+		}
+	}
+	if len(plain) == 1 {
+		return plain, nil
+	}
+	return nil, fmt.Errorf("found %d executables; set binary_glob for this app", len(elfs))
+}
+
+func isELF(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, len(elfMagic))
+	n, _ := io.ReadFull(f, head)
+	return bytes.Equal(head[:n], elfMagic)
+}
@@ -0,0 +1,170 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/system"
+)
+
+// fakeELF is enough of an executable for FindBinaries to recognise.
+const fakeELF = "\x7fELF\x02\x01\x01\x00fake"
+
+func writeTarGz(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for name, body := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write([]byte(body))
+	}
+	tw.Close()
+	gz.Close()
+}
+
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, body := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	zw.Close()
+}
+
+func TestDetectArchiveTypes(t *testing.T) {
+	tests := map[string]packages.Type{
+		"tool_linux_amd64.tar.gz":   packages.Archive,
+		"tool-v1.2.0-linux-x64.zip": packages.Archive,
+		"tool_darwin_arm64.tar.gz":  packages.Unknown,
+		"tool-linux-amd64":          packages.RawBinary,
+		"tool_1.2.0_linux_amd64":    packages.RawBinary,
+		"tool-linux-amd64.sha256":   packages.Unknown,
+		"tool-linux-amd64.exe":      packages.Unknown,
+	}
+	for name, want := range tests {
+		if got := packages.DetectType(name); got != want {
+			t.Errorf("DetectType(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestInstallBinaries_TarGz(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	archive := filepath.Join(t.TempDir(), "tool_linux_amd64.tar.gz")
+	writeTarGz(t, archive, map[string]string{
+		"tool_linux_amd64/README.md":     "docs",
+		"tool_linux_amd64/tool":          fakeELF,
+		"tool_linux_amd64/tool-debug":    fakeELF,
+		"tool_linux_amd64/completion.sh": "#!/bin/sh",
+	})
+
+	rec, err := InstallUserPackage(Package{Path: archive}, "Tool", "v1.0.0")
+	if err != nil {
+		t.Fatalf("InstallUserPackage() error = %v", err)
+	}
+	bin := filepath.Join(home, ".local", "bin", "tool")
+	if rec.Path != bin || len(rec.Files) != 1 {
+		t.Errorf("installed %v, want only %s", rec.Files, bin)
+	}
+	if fi, err := os.Stat(bin); err != nil || fi.Mode().Perm()&0100 == 0 {
+		t.Errorf("tool is not installed as an executable: %v", err)
+	}
+
+	got, ok := system.LoadLocalInstall("Tool")
+	if !ok || got.Version != "v1.0.0" || got.Type != packages.Archive {
+		t.Errorf("LoadLocalInstall() = %+v, %v; want v1.0.0 archive", got, ok)
+	}
+
+	cmd, err := GetUninstallCmd(packages.Archive, got.Name)
+	if err != nil {
+		t.Fatalf("GetUninstallCmd() error = %v", err)
+	}
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("uninstall: %v", err)
+	}
+	if _, err := os.Stat(bin); !os.IsNotExist(err) {
+		t.Error("binary is still installed after uninstalling")
+	}
+}
+
+func TestInstallBinaries_ZipGlob(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+	binDir := filepath.Join(home, "tools")
+
+	archive := filepath.Join(t.TempDir(), "suite-linux-x64.zip")
+	writeZip(t, archive, map[string]string{
+		"bin/suite-server": fakeELF,
+		"bin/suite-client": fakeELF,
+		"lib/libsuite.so":  fakeELF,
+	})
+
+	// Several executables are ambiguous without a glob
+	if _, err := InstallUserPackage(Package{Path: archive, BinDir: binDir}, "Suite", "v2"); err == nil {
+		t.Fatal("InstallUserPackage() picked one of several executables")
+	}
+
+	rec, err := InstallUserPackage(Package{Path: archive, BinaryGlob: "bin/*", BinDir: binDir}, "Suite", "v2")
+	if err != nil {
+		t.Fatalf("InstallUserPackage() error = %v", err)
+	}
+	want := []string{filepath.Join(binDir, "suite-client"), filepath.Join(binDir, "suite-server")}
+	if len(rec.Files) != 2 || rec.Files[0] != want[0] || rec.Files[1] != want[1] {
+		t.Errorf("installed %v, want %v", rec.Files, want)
+	}
+}
+
+func TestInstallBinaries_KeepsForeignBinary(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_STATE_HOME", filepath.Join(home, "state"))
+
+	bin := filepath.Join(home, ".local", "bin", "tool")
+	os.MkdirAll(filepath.Dir(bin), 0755)
+	os.WriteFile(bin, []byte("not ours"), 0755)
+
+	raw := filepath.Join(t.TempDir(), "tool-linux-amd64")
+	os.WriteFile(raw, []byte(fakeELF), 0644)
+	if _, err := InstallBinaries(Package{Path: raw}, "tool", "v1"); err == nil {
+		t.Fatal("InstallBinaries() replaced a binary it did not create")
+	}
+	if data, _ := os.ReadFile(bin); string(data) != "not ours" {
+		t.Error("existing binary was modified")
+	}
+}
+
+func TestExtractArchive_RejectsTraversal(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "evil_linux_amd64.tar.gz")
+	writeTarGz(t, archive, map[string]string{"../../evil": fakeELF})
+	if err := ExtractArchive(archive, t.TempDir()); err == nil {
+		t.Error("ExtractArchive() accepted an entry outside the archive")
+	}
+}
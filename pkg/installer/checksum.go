@@ -0,0 +1,279 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/verify"
+)
+
+// ChecksumStatus is the outcome of verifying a downloaded asset.
+type ChecksumStatus string
+
+const (
+	ChecksumVerified   ChecksumStatus = "verified"
+	ChecksumUnverified ChecksumStatus = "unverified"
+	ChecksumMismatch   ChecksumStatus = "mismatch"
+)
+
+// ChecksumResult describes how a downloaded asset was verified.
+type ChecksumResult struct {
+	Status   ChecksumStatus
+	Source   string // checksum asset name, or "GitHub digest"
+	List     string // checksum asset that lists the file, if any
+	Expected string
+	Actual   string
+
+	listData []byte // List as it was read, for its signature check
+}
+
+func (r ChecksumResult) String() string {
+	if r.Source == "" {
+		return string(r.Status)
+	}
+	return fmt.Sprintf("%s (%s)", r.Status, r.Source)
+}
+
+// ChecksumMismatchError is returned when a download does not match its
+// published checksum. Such a file must never be installed.
+type ChecksumMismatchError struct {
+	Asset  string
+	Result ChecksumResult
+}
+
+func (e *ChecksumMismatchError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: %s lists %s, downloaded file is %s",
+		e.Asset, e.Result.Source, e.Result.Expected, e.Result.Actual)
+}
+
+// digestSource is the ChecksumResult.Source of GitHub's asset digest.
+const digestSource = "GitHub digest"
+
+// maxChecksumFileSize bounds how much of a checksum asset is read.
+const maxChecksumFileSize = 1 << 20
+
+// Verification records how a downloaded asset was checked.
+type Verification struct {
+	Checksum  ChecksumResult
+	Signature verify.Result
+}
+
+func (v Verification) String() string {
+	return fmt.Sprintf("checksum %s, signature %s", v.Checksum, v.Signature)
+}
+
+// DownloadVerifiedAsset downloads asset and verifies it against the checksums
+// and signatures published with release. If the checksum does not match, or
+// trust's signature policy rejects the file, it is removed and a
+// *ChecksumMismatchError or *verify.SignatureError is returned. progress may
+// be nil.
+func DownloadVerifiedAsset(release *github.Release, asset *github.Asset, trust verify.Trust, progress ProgressFunc) (string, Verification, error) {
+	path, err := DownloadAsset(asset, progress)
+	if err != nil {
+		return "", Verification{}, err
+	}
+
+	var v Verification
+	v.Checksum, err = VerifyChecksum(path, release, *asset)
+	if err != nil {
+		os.Remove(path)
+		// Don't serve a corrupt download from the cache again
+		forgetCached(*asset)
+		return "", v, err
+	}
+
+	// A signed checksum list vouches for every file it verified
+	var list *verify.ChecksumList
+	if v.Checksum.List != "" {
+		list = &verify.ChecksumList{Name: v.Checksum.List, Data: v.Checksum.listData}
+	}
+	v.Signature, err = verify.Verify(path, release, *asset, list, trust)
+	if err != nil {
+		os.Remove(path)
+		return "", v, err
+	}
+	return path, v, nil
+}
+
+// lockSource is the ChecksumResult.Source of a hash pinned in a lockfile.
+const lockSource = "autonomix.lock"
+
+// DownloadLockedAsset is DownloadVerifiedAsset for an asset pinned by a
+// lockfile: the download must hash to sum, whatever the release
+// publishes, or a *ChecksumMismatchError is returned. sum is the hex
+// SHA-256 of the locked file.
+func DownloadLockedAsset(release *github.Release, asset *github.Asset, sum string, trust verify.Trust, progress ProgressFunc) (string, Verification, error) {
+	pinned := *asset
+	pinned.Digest = "sha256:" + strings.ToLower(sum)
+	path, v, err := DownloadVerifiedAsset(release, &pinned, trust, progress)
+	if v.Checksum.Source == digestSource {
+		v.Checksum.Source = lockSource
+	}
+	var mismatch *ChecksumMismatchError
+	if errors.As(err, &mismatch) {
+		mismatch.Result.Source = lockSource
+	}
+	return path, v, err
+}
+
+// VerifyChecksum checks the file at path against asset's GitHub digest and
+// the checksum files in release. Only a mismatch is an error; if no checksum
+// is published the result is ChecksumUnverified. A checksum list naming the
+// asset is looked up even when the digest is known, so that a signature on
+// the list can vouch for the file; it must then agree with the digest.
+func VerifyChecksum(path string, release *github.Release, asset github.Asset) (ChecksumResult, error) {
+	actual, err := fileSHA256(path)
+	if err != nil {
+		return ChecksumResult{}, err
+	}
+
+	listed, list, data := listedChecksum(release, asset)
+	result := ChecksumResult{Status: ChecksumVerified, Source: list, List: list, Expected: listed, Actual: actual, listData: data}
+	if digest, ok := strings.CutPrefix(asset.Digest, "sha256:"); ok {
+		digest = strings.ToLower(digest)
+		if listed != "" && listed != digest {
+			result.Status = ChecksumMismatch
+			return result, &ChecksumMismatchError{Asset: asset.Name, Result: result}
+		}
+		result.Source, result.Expected = digestSource, digest
+	}
+
+	if result.Expected == "" {
+		return ChecksumResult{Status: ChecksumUnverified, Actual: actual}, nil
+	}
+	if result.Expected != actual {
+		result.Status = ChecksumMismatch
+		return result, &ChecksumMismatchError{Asset: asset.Name, Result: result}
+	}
+	return result, nil
+}
+
+// listedChecksum returns the SHA-256 of asset from the first checksum file in
+// release that lists it, that file's name and its contents.
+func listedChecksum(release *github.Release, asset github.Asset) (sum, source string, data []byte) {
+	if release == nil {
+		return "", "", nil
+	}
+	for _, candidate := range FindChecksumAssets(release, asset) {
+		data, err := github.FetchAsset(candidate, maxChecksumFileSize)
+		if err != nil {
+			continue
+		}
+		if sum := ParseChecksum(data, asset.Name, isAssetChecksum(candidate.Name, asset.Name)); sum != "" {
+			return sum, candidate.Name, data
+		}
+	}
+	return "", "", nil
+}
+
+// FindChecksumAssets returns the assets of release that may hold the checksum
+// of asset, most specific first: "<asset>.sha256" style files, then
+// SHA256SUMS, checksums.txt and goreleaser's "<project>_<version>_checksums.txt".
+func FindChecksumAssets(release *github.Release, asset github.Asset) []github.Asset {
+	var specific, combined []github.Asset
+	for _, a := range release.Assets {
+		if a.Name == asset.Name {
+			continue
+		}
+		switch {
+		case isAssetChecksum(a.Name, asset.Name):
+			specific = append(specific, a)
+		case isChecksumList(strings.ToLower(a.Name)):
+			combined = append(combined, a)
+		}
+	}
+	return append(specific, combined...)
+}
+
+// isAssetChecksum reports whether the file called name holds the checksum of
+// asset alone, e.g. "<asset>.sha256".
+func isAssetChecksum(name, asset string) bool {
+	lower, asset := strings.ToLower(name), strings.ToLower(asset)
+	return lower == asset+".sha256" || lower == asset+".sha256sum" || lower == asset+".sha256.txt"
+}
+
+func isChecksumList(lower string) bool {
+	for _, ext := range []string{".sig", ".asc", ".pem", ".minisig", ".bundle", ".sbom", ".json"} {
+		if strings.HasSuffix(lower, ext) {
+			return false
+		}
+	}
+	base := strings.TrimSuffix(lower, ".txt")
+	return base == "sha256sums" || base == "sha256sum" || base == "checksums" ||
+		strings.HasSuffix(base, "_checksums") || strings.HasSuffix(base, "-checksums") ||
+		strings.HasSuffix(base, "_sha256sums") || strings.HasSuffix(base, "-sha256sums") ||
+		strings.HasSuffix(base, ".sha256sums")
+}
+
+// ParseChecksum finds the SHA-256 of name in a checksum file. It understands
+// the sha256sum format ("<hash>  <name>", optionally "*<name>" or a path) and
+// the BSD format ("SHA256 (<name>) = <hash>"). single reports whether the file
+// holds the checksum of name alone, such as "<name>.sha256"; only then is a
+// bare hash accepted, as in a combined list it could belong to any file.
+func ParseChecksum(data []byte, name string, single bool) string {
+	var bare []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if rest, ok := strings.CutPrefix(line, "SHA256 ("); ok {
+			file, sum, found := strings.Cut(rest, ") = ")
+			if found && path.Base(file) == name && isSHA256(sum) {
+				return strings.ToLower(sum)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if !isSHA256(fields[0]) {
+			continue
+		}
+		if len(fields) == 1 {
+			bare = append(bare, fields[0])
+			continue
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		if path.Base(file) == name {
+			return strings.ToLower(fields[0])
+		}
+	}
+	// A file with a single bare hash, e.g. "<asset>.sha256"
+	if single && len(bare) == 1 {
+		return strings.ToLower(bare[0])
+	}
+	return ""
+}
+
+func isSHA256(s string) bool {
+	if len(s) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,125 @@
+package installer
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tim/autonomix-cli/pkg/github"
+)
+
+const (
+	helloSHA256 = "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" // "hello"
+	otherSHA256 = "486ea46224d1bb4fb680f34f7c9ad96a8f24ec88be73ea8e5a6c65260e9cb8a7" // "world"
+)
+
+func TestParseChecksum(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		single bool
+		want   string
+	}{
+		{"sha256sum", otherSHA256 + "  other.deb\n" + helloSHA256 + "  app.deb\n", false, helloSHA256},
+		{"binary mode", helloSHA256 + " *app.deb\n", false, helloSHA256},
+		{"with path", helloSHA256 + "  dist/app.deb\n", false, helloSHA256},
+		{"bsd", "SHA256 (other.deb) = " + otherSHA256 + "\nSHA256 (app.deb) = " + helloSHA256 + "\n", false, helloSHA256},
+		{"bare hash", helloSHA256 + "\n", true, helloSHA256},
+		{"bare hash in a combined list", helloSHA256 + "\n", false, ""},
+		{"uppercase", "2CF24DBA5FB0A30E26E83B2AC5B9E29E1B161E5C1FA7425E73043362938B9824  app.deb\n", false, helloSHA256},
+		{"not listed", otherSHA256 + "  other.deb\n", false, ""},
+		{"ambiguous bare", helloSHA256 + "\n" + otherSHA256 + "\n", true, ""},
+		{"garbage", "not a checksum file\n", true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseChecksum([]byte(tt.data), "app.deb", tt.single); got != tt.want {
+				t.Errorf("ParseChecksum() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindChecksumAssets(t *testing.T) {
+	release := &github.Release{Assets: []github.Asset{
+		{Name: "app_1.0.0_amd64.deb"},
+		{Name: "app_1.0.0_checksums.txt"},
+		{Name: "app_1.0.0_checksums.txt.sig"},
+		{Name: "SHA256SUMS"},
+		{Name: "app_1.0.0_amd64.deb.sha256"},
+		{Name: "other_1.0.0_amd64.deb.sha256"},
+		{Name: "app_1.0.0.tar.gz"},
+	}}
+
+	got := FindChecksumAssets(release, release.Assets[0])
+	want := []string{"app_1.0.0_amd64.deb.sha256", "app_1.0.0_checksums.txt", "SHA256SUMS"}
+	if len(got) != len(want) {
+		t.Fatalf("FindChecksumAssets() returned %d assets, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Name != want[i] {
+			t.Errorf("asset %d = %s, want %s", i, got[i].Name, want[i])
+		}
+	}
+}
+
+func TestVerifyChecksum(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/SHA256SUMS":
+			w.Write([]byte(helloSHA256 + "  app.deb\n"))
+		case "/bad/SHA256SUMS":
+			w.Write([]byte(otherSHA256 + "  app.deb\n"))
+		case "/bare/SHA256SUMS":
+			w.Write([]byte(otherSHA256 + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_TOKEN", "")
+
+	path := filepath.Join(t.TempDir(), "app.deb")
+	if err := os.WriteFile(path, []byte("hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sums := func(url string) *github.Release {
+		return &github.Release{Assets: []github.Asset{
+			{Name: "app.deb"},
+			{Name: "SHA256SUMS", BrowserDownloadURL: url},
+		}}
+	}
+
+	tests := []struct {
+		name    string
+		release *github.Release
+		asset   github.Asset
+		want    ChecksumStatus
+		source  string
+	}{
+		{"digest", nil, github.Asset{Name: "app.deb", Digest: "sha256:" + helloSHA256}, ChecksumVerified, "GitHub digest"},
+		{"digest mismatch", nil, github.Asset{Name: "app.deb", Digest: "sha256:" + otherSHA256}, ChecksumMismatch, "GitHub digest"},
+		{"digest and checksum file", sums(server.URL + "/SHA256SUMS"), github.Asset{Name: "app.deb", Digest: "sha256:" + helloSHA256}, ChecksumVerified, "GitHub digest"},
+		{"checksum file disagrees with digest", sums(server.URL + "/SHA256SUMS"), github.Asset{Name: "app.deb", Digest: "sha256:" + otherSHA256}, ChecksumMismatch, "SHA256SUMS"},
+		{"checksum file", sums(server.URL + "/SHA256SUMS"), github.Asset{Name: "app.deb"}, ChecksumVerified, "SHA256SUMS"},
+		{"checksum file mismatch", sums(server.URL + "/bad/SHA256SUMS"), github.Asset{Name: "app.deb"}, ChecksumMismatch, "SHA256SUMS"},
+		{"bare hash in a combined list", sums(server.URL + "/bare/SHA256SUMS"), github.Asset{Name: "app.deb"}, ChecksumUnverified, ""},
+		{"unreachable checksum file", sums(server.URL + "/missing"), github.Asset{Name: "app.deb"}, ChecksumUnverified, ""},
+		{"nothing published", &github.Release{}, github.Asset{Name: "app.deb"}, ChecksumUnverified, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := VerifyChecksum(path, tt.release, tt.asset)
+			if result.Status != tt.want || result.Source != tt.source {
+				t.Errorf("VerifyChecksum() = %s (%s), want %s (%s)", result.Status, result.Source, tt.want, tt.source)
+			}
+			var mismatch *ChecksumMismatchError
+			if isMismatch := errors.As(err, &mismatch); isMismatch != (tt.want == ChecksumMismatch) {
+				t.Errorf("VerifyChecksum() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,427 @@
+package installer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+)
+
+// Progress reports how far a download has come.
+type Progress struct {
+	Asset string
+	Done  int64
+	Total int64   // 0 if the server did not say
+	Rate  float64 // bytes per second
+	ETA   time.Duration
+	// Cached is set when the asset came from the download cache.
+	Cached bool
+}
+
+// ProgressFunc receives the progress of a download. It is called from the
+// downloading goroutine, at most a few times per second.
+type ProgressFunc func(Progress)
+
+// Fraction returns how much of the download is done, from 0 to 1, or 0 if
+// the size is unknown.
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return min(float64(p.Done)/float64(p.Total), 1)
+}
+
+func (p Progress) String() string {
+	if p.Cached {
+		return fmt.Sprintf("%s (cached)", formatBytes(p.Done))
+	}
+	s := formatBytes(p.Done)
+	if p.Total > 0 {
+		s += " / " + formatBytes(p.Total)
+	}
+	if p.Rate > 0 {
+		s += fmt.Sprintf(", %s/s", formatBytes(int64(p.Rate)))
+	}
+	if p.ETA > 0 {
+		s += fmt.Sprintf(", %s left", p.ETA.Round(time.Second))
+	}
+	return s
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// progressInterval throttles ProgressFunc calls.
+const progressInterval = 200 * time.Millisecond
+
+// stallTimeout aborts a download that has received nothing for this long.
+// What was received is kept, so the next attempt resumes from there.
+var stallTimeout = 60 * time.Second
+
+// downloadClient has connection timeouts but no overall one, since a large
+// asset may take a long time on a slow link; stalls are caught separately.
+var downloadClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+		TLSHandshakeTimeout:   15 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+	},
+}
+
+// downloadCacheDir is overridden in tests; empty means
+// config.GetCacheDir()/downloads.
+var downloadCacheDir string
+
+// DownloadCacheDir returns where downloaded assets are cached. Files are
+// stored by SHA-256 under sha256/, indexed by URL under urls/, and
+// interrupted downloads are kept under partial/ until resumed. The files
+// handed to installers are linked into files/.
+func DownloadCacheDir() (string, error) {
+	if downloadCacheDir != "" {
+		return downloadCacheDir, nil
+	}
+	dir, err := config.GetCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "downloads"), nil
+}
+
+// CleanDownloadCache removes every cached and partial download and returns
+// the number of bytes freed.
+func CleanDownloadCache() (int64, error) {
+	dir, err := DownloadCacheDir()
+	if err != nil {
+		return 0, err
+	}
+	var freed int64
+	filepath.Walk(dir, func(_ string, fi os.FileInfo, err error) error {
+		if err == nil && fi.Mode().IsRegular() {
+			freed += fi.Size()
+		}
+		return nil
+	})
+	if err := os.RemoveAll(dir); err != nil {
+		return 0, err
+	}
+	return freed, nil
+}
+
+// cacheIndex records which blob holds the asset downloaded from URL.
+type cacheIndex struct {
+	URL    string `json:"url"`
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// partialMeta records how to resume an interrupted download.
+type partialMeta struct {
+	URL  string `json:"url"`
+	ETag string `json:"etag,omitempty"`
+}
+
+func urlKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:])
+}
+
+// DownloadAsset downloads asset into the files/ directory of the download
+// cache and returns the path of the file. The asset is served from the download cache when
+// possible; otherwise an interrupted earlier attempt is resumed with a
+// Range request. progress may be nil.
+func DownloadAsset(asset *github.Asset, progress ProgressFunc) (string, error) {
+	if progress == nil {
+		progress = func(Progress) {}
+	}
+	dir, err := DownloadCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dest := filepath.Join(dir, "files", filepath.Base(asset.Name))
+
+	if blob, size, ok := cachedBlob(dir, *asset); ok {
+		progress(Progress{Asset: asset.Name, Done: size, Total: size, Cached: true})
+		return dest, placeFile(blob, dest)
+	}
+
+	blob, err := fetchToCache(dir, *asset, progress)
+	if err != nil {
+		return "", fmt.Errorf("failed to download: %w", err)
+	}
+	return dest, placeFile(blob, dest)
+}
+
+// cachedBlob returns the cached copy of asset, if one exists and still has
+// the hash it is stored under.
+func cachedBlob(dir string, asset github.Asset) (string, int64, bool) {
+	sum, ok := strings.CutPrefix(asset.Digest, "sha256:")
+	if !ok {
+		data, err := os.ReadFile(filepath.Join(dir, "urls", urlKey(asset.BrowserDownloadURL)+".json"))
+		if err != nil {
+			return "", 0, false
+		}
+		var idx cacheIndex
+		if json.Unmarshal(data, &idx) != nil || idx.URL != asset.BrowserDownloadURL {
+			return "", 0, false
+		}
+		sum = idx.SHA256
+	}
+	blob := filepath.Join(dir, "sha256", strings.ToLower(sum))
+	fi, err := os.Stat(blob)
+	if err != nil {
+		return "", 0, false
+	}
+	if actual, err := fileSHA256(blob); err != nil || actual != strings.ToLower(sum) {
+		os.Remove(blob)
+		return "", 0, false
+	}
+	return blob, fi.Size(), true
+}
+
+// forgetCached removes asset from the download cache, e.g. after it failed
+// verification.
+func forgetCached(asset github.Asset) {
+	dir, err := DownloadCacheDir()
+	if err != nil {
+		return
+	}
+	if blob, _, ok := cachedBlob(dir, asset); ok {
+		os.Remove(blob)
+	}
+	os.Remove(filepath.Join(dir, "urls", urlKey(asset.BrowserDownloadURL)+".json"))
+}
+
+// fetchToCache downloads asset into the cache and returns the blob path.
+func fetchToCache(dir string, asset github.Asset, progress ProgressFunc) (string, error) {
+	key := urlKey(asset.BrowserDownloadURL)
+	partial := filepath.Join(dir, "partial", key)
+	metaPath := partial + ".json"
+	if err := os.MkdirAll(filepath.Dir(partial), 0755); err != nil {
+		return "", err
+	}
+
+	var meta partialMeta
+	if data, err := os.ReadFile(metaPath); err == nil {
+		json.Unmarshal(data, &meta)
+	}
+	var offset int64
+	if fi, err := os.Stat(partial); err == nil && meta.URL == asset.BrowserDownloadURL {
+		offset = fi.Size()
+	}
+
+	resp, err := requestFrom(asset.BrowserDownloadURL, offset, meta.ETag)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable ||
+		resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) != offset {
+		// The partial file is stale or already complete; start over
+		resp.Body.Close()
+		offset = 0
+		if resp, err = requestFrom(asset.BrowserDownloadURL, 0, ""); err != nil {
+			return "", err
+		}
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	total := resp.ContentLength
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) == offset:
+		flags |= os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+	case resp.StatusCode == http.StatusOK:
+		// The server ignored the range or the file changed
+		offset = 0
+		flags |= os.O_TRUNC
+	case resp.StatusCode == http.StatusUnauthorized:
+		return "", github.CheckResponse(resp)
+	default:
+		return "", fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	meta = partialMeta{URL: asset.BrowserDownloadURL, ETag: resp.Header.Get("ETag")}
+	if data, err := json.Marshal(meta); err == nil {
+		os.WriteFile(metaPath, data, 0644)
+	}
+
+	out, err := os.OpenFile(partial, flags, 0644)
+	if err != nil {
+		return "", err
+	}
+	start := time.Now()
+	last := start
+	done := offset
+	report := func(force bool) {
+		now := time.Now()
+		if !force && now.Sub(last) < progressInterval {
+			return
+		}
+		last = now
+		p := Progress{Asset: asset.Name, Done: done, Total: max(total, 0)}
+		if elapsed := now.Sub(start).Seconds(); elapsed > 0 {
+			p.Rate = float64(done-offset) / elapsed
+		}
+		if p.Rate > 0 && p.Total > done {
+			p.ETA = time.Duration(float64(p.Total-done) / p.Rate * float64(time.Second))
+		}
+		progress(p)
+	}
+	report(true)
+
+	_, err = io.Copy(out, &progressReader{r: resp.Body, onRead: func(n int) {
+		done += int64(n)
+		report(false)
+	}})
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			err = fmt.Errorf("no data received for %s", stallTimeout)
+		}
+		return "", fmt.Errorf("%w (%s kept, the next attempt resumes)", err, formatBytes(done))
+	}
+	if total > 0 && done != total {
+		return "", fmt.Errorf("incomplete download: got %s of %s", formatBytes(done), formatBytes(total))
+	}
+	report(true)
+
+	sum, err := fileSHA256(partial)
+	if err != nil {
+		return "", err
+	}
+	blob := filepath.Join(dir, "sha256", sum)
+	if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(partial, blob); err != nil {
+		return "", err
+	}
+	os.Remove(metaPath)
+
+	idx := cacheIndex{URL: asset.BrowserDownloadURL, Name: asset.Name, SHA256: sum, Size: done}
+	if data, err := json.Marshal(idx); err == nil {
+		idxPath := filepath.Join(dir, "urls", key+".json")
+		if os.MkdirAll(filepath.Dir(idxPath), 0755) == nil {
+			os.WriteFile(idxPath, data, 0644)
+		}
+	}
+	return blob, nil
+}
+
+// requestFrom starts a download of url at offset. The request is cancelled
+// when the body stalls for stallTimeout.
+func requestFrom(url string, offset int64, etag string) (*http.Response, error) {
+	req, err := github.NewRequest(url)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if etag != "" {
+			// Only resume if the file is unchanged, otherwise get all of it
+			req.Header.Set("If-Range", etag)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	timer := time.AfterFunc(stallTimeout, cancel)
+	resp, err := downloadClient.Do(req.WithContext(ctx))
+	if err != nil {
+		timer.Stop()
+		cancel()
+		return nil, err
+	}
+	resp.Body = &stallBody{ReadCloser: resp.Body, timer: timer, cancel: cancel}
+	return resp, nil
+}
+
+// stallBody resets the stall timer on every read.
+type stallBody struct {
+	io.ReadCloser
+	timer  *time.Timer
+	cancel context.CancelFunc
+}
+
+func (b *stallBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		b.timer.Reset(stallTimeout)
+	}
+	return n, err
+}
+
+func (b *stallBody) Close() error {
+	b.timer.Stop()
+	b.cancel()
+	return b.ReadCloser.Close()
+}
+
+type progressReader struct {
+	r      io.Reader
+	onRead func(int)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if n > 0 {
+		r.onRead(n)
+	}
+	return n, err
+}
+
+// contentRangeStart returns the first byte of a 206 response, or -1.
+func contentRangeStart(resp *http.Response) int64 {
+	spec, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	first, _, _ := strings.Cut(spec, "-")
+	n, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// placeFile puts a copy of the cached blob at dest, hard-linking it when
+// possible. Callers delete dest after installing; the blob stays cached.
+func placeFile(blob, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	os.Remove(dest)
+	if os.Link(blob, dest) == nil {
+		return nil
+	}
+	return copyFileAtomic(blob, dest, 0644)
+}
@@ -0,0 +1,211 @@
+package installer
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/verify"
+)
+
+// assetServer serves body with Range support and records the requests.
+type assetServer struct {
+	*httptest.Server
+	mu     sync.Mutex
+	ranges []string
+}
+
+func newAssetServer(t *testing.T, body []byte, etag string) *assetServer {
+	s := &assetServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.ranges = append(s.ranges, r.Header.Get("Range"))
+		s.mu.Unlock()
+		w.Header().Set("ETag", etag)
+		http.ServeContent(w, r, "asset", time.Time{}, bytes.NewReader(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func useDownloadCache(t *testing.T) string {
+	t.Helper()
+	old := downloadCacheDir
+	downloadCacheDir = t.TempDir()
+	t.Cleanup(func() { downloadCacheDir = old })
+	return downloadCacheDir
+}
+
+// seedPartial leaves the first n bytes of body as an interrupted download.
+func seedPartial(t *testing.T, dir, url, etag string, body []byte, n int) {
+	t.Helper()
+	partial := filepath.Join(dir, "partial", urlKey(url))
+	os.MkdirAll(filepath.Dir(partial), 0755)
+	os.WriteFile(partial, body[:n], 0644)
+	meta, _ := json.Marshal(partialMeta{URL: url, ETag: etag})
+	os.WriteFile(partial+".json", meta, 0644)
+}
+
+func TestDownloadAsset_ResumesAndCaches(t *testing.T) {
+	dir := useDownloadCache(t)
+	body := bytes.Repeat([]byte("0123456789"), 10000)
+	srv := newAssetServer(t, body, `"v1"`)
+	asset := &github.Asset{Name: "tool_linux_amd64.tar.gz", BrowserDownloadURL: srv.URL + "/tool_linux_amd64.tar.gz"}
+	seedPartial(t, dir, asset.BrowserDownloadURL, `"v1"`, body, 40000)
+
+	var last Progress
+	path, err := DownloadAsset(asset, func(p Progress) { last = p })
+	if err != nil {
+		t.Fatalf("DownloadAsset() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, body) {
+		t.Fatal("resumed download differs from the asset")
+	}
+	if len(srv.ranges) != 1 || srv.ranges[0] != "bytes=40000-" {
+		t.Errorf("requests = %q, want one for bytes=40000-", srv.ranges)
+	}
+	if last.Done != int64(len(body)) || last.Total != int64(len(body)) {
+		t.Errorf("last progress = %+v, want %d of %d", last, len(body), len(body))
+	}
+
+	// Reinstalling is served from the cache
+	os.Remove(path)
+	path, err = DownloadAsset(asset, func(p Progress) { last = p })
+	if err != nil {
+		t.Fatalf("cached DownloadAsset() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, body) || !last.Cached {
+		t.Error("second download was not served from the cache")
+	}
+	if len(srv.ranges) != 1 {
+		t.Errorf("cached download made %d more requests", len(srv.ranges)-1)
+	}
+
+	freed, err := CleanDownloadCache()
+	if err != nil || freed < int64(len(body)) {
+		t.Errorf("CleanDownloadCache() = %d, %v", freed, err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Error("cache directory still exists after cleaning")
+	}
+}
+
+func TestDownloadAsset_RestartsChangedFile(t *testing.T) {
+	dir := useDownloadCache(t)
+	body := bytes.Repeat([]byte("abcdefgh"), 4096)
+	srv := newAssetServer(t, body, `"v2"`)
+	asset := &github.Asset{Name: "tool-linux-amd64", BrowserDownloadURL: srv.URL + "/tool-linux-amd64"}
+
+	// The partial file belongs to an older upload of the asset
+	stale := bytes.Repeat([]byte("x"), len(body))
+	seedPartial(t, dir, asset.BrowserDownloadURL, `"v1"`, stale, 1000)
+
+	path, err := DownloadAsset(asset, nil)
+	if err != nil {
+		t.Fatalf("DownloadAsset() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, body) {
+		t.Error("download kept bytes of the stale partial file")
+	}
+}
+
+func TestDownloadLockedAsset(t *testing.T) {
+	useDownloadCache(t)
+	srv := newAssetServer(t, []byte("hello"), `"v1"`)
+	// GitHub's digest is ignored in favour of the locked hash
+	asset := &github.Asset{Name: "app.deb", BrowserDownloadURL: srv.URL + "/app.deb", Digest: "sha256:" + otherSHA256}
+	release := &github.Release{TagName: "v1.0.0", Assets: []github.Asset{*asset}}
+	trust := verify.Trust{Policy: verify.PolicyOff}
+
+	path, v, err := DownloadLockedAsset(release, asset, helloSHA256, trust, nil)
+	if err != nil {
+		t.Fatalf("DownloadLockedAsset() error = %v", err)
+	}
+	os.Remove(path)
+	if v.Checksum.Status != ChecksumVerified || v.Checksum.Source != "autonomix.lock" {
+		t.Errorf("checksum = %s, want verified (autonomix.lock)", v.Checksum)
+	}
+
+	_, _, err = DownloadLockedAsset(release, asset, otherSHA256, trust, nil)
+	var mismatch *ChecksumMismatchError
+	if !errors.As(err, &mismatch) || mismatch.Result.Source != "autonomix.lock" {
+		t.Errorf("DownloadLockedAsset() with another hash error = %v, want a lockfile mismatch", err)
+	}
+}
+
+func TestDownloadVerifiedAsset_DigestAndSignedChecksums(t *testing.T) {
+	useDownloadCache(t)
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	sums := []byte(helloSHA256 + "  app.deb\n")
+	digest := sha256.Sum256(sums)
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := map[string][]byte{
+		"app.deb":           []byte("hello"),
+		"checksums.txt":     sums,
+		"checksums.txt.sig": []byte(base64.StdEncoding.EncodeToString(sig)),
+	}
+	var mu sync.Mutex
+	fetched := map[string]int{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched[r.URL.Path[1:]]++
+		mu.Unlock()
+		data, ok := files[r.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "asset", time.Time{}, bytes.NewReader(data))
+	}))
+	defer server.Close()
+	t.Setenv("GITHUB_TOKEN", "")
+
+	release := &github.Release{TagName: "v1.0.0"}
+	for _, name := range []string{"app.deb", "checksums.txt", "checksums.txt.sig"} {
+		release.Assets = append(release.Assets, github.Asset{Name: name, BrowserDownloadURL: server.URL + "/" + name})
+	}
+	asset := release.Assets[0]
+	asset.Digest = "sha256:" + helloSHA256
+	trust := verify.Trust{Policy: verify.PolicyRequire, Keys: []config.PublicKey{{Type: verify.KeyCosign, Key: key}}}
+
+	path, v, err := DownloadVerifiedAsset(release, &asset, trust, nil)
+	if err != nil {
+		t.Fatalf("DownloadVerifiedAsset() error = %v", err)
+	}
+	os.Remove(path)
+	if v.Checksum.Source != "GitHub digest" || v.Checksum.List != "checksums.txt" {
+		t.Errorf("checksum = %+v, want the digest confirmed by checksums.txt", v.Checksum)
+	}
+	if v.Signature.Status != verify.Verified || v.Signature.Signature != "checksums.txt.sig" {
+		t.Errorf("signature = %s, want verified by checksums.txt.sig", v.Signature)
+	}
+	// The signature is checked on the very list the checksum came from
+	if fetched["checksums.txt"] != 1 {
+		t.Errorf("checksums.txt fetched %d times, want once", fetched["checksums.txt"])
+	}
+}
@@ -0,0 +1,52 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// maxBundleHeader bounds how much of a .flatpak bundle is searched for its ref.
+const maxBundleHeader = 1 << 20
+
+// bundleRef matches the "app/<id>/<arch>/<branch>" ref stored in the
+// metadata at the start of a flatpak bundle.
+var bundleRef = regexp.MustCompile(`app/([A-Za-z_][A-Za-z0-9_-]*(?:\.[A-Za-z0-9_-]+)+)/[A-Za-z0-9_]+/[A-Za-z0-9_.-]+`)
+
+// FlatpakID returns the application ID of a .flatpak bundle or .flatpakref file.
+func FlatpakID(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(strings.ToLower(path), ".flatpakref") {
+		var group string
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if strings.HasPrefix(line, "[") {
+				group = line
+				continue
+			}
+			if key, value, ok := strings.Cut(line, "="); ok && group == "[Flatpak Ref]" && strings.TrimSpace(key) == "Name" {
+				return strings.TrimSpace(value), nil
+			}
+		}
+		return "", fmt.Errorf("%s has no application name", path)
+	}
+
+	header, err := io.ReadAll(io.LimitReader(f, maxBundleHeader))
+	if err != nil {
+		return "", err
+	}
+	if m := bundleRef.FindSubmatch(header); m != nil && bytes.Count(m[1], []byte(".")) >= 2 {
+		return string(m[1]), nil
+	}
+	return "", fmt.Errorf("%s has no application ref", path)
+}
@@ -0,0 +1,62 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFlatpakID(t *testing.T) {
+	dir := t.TempDir()
+	ref := filepath.Join(dir, "app.flatpakref")
+	os.WriteFile(ref, []byte("[Flatpak Ref]\nTitle=My App\nName=org.example.MyApp\nBranch=stable\nUrl=https://dl.flathub.org/repo/\n"), 0644)
+
+	// Bundles keep their ref in a binary metadata header
+	bundle := filepath.Join(dir, "MyApp.flatpak")
+	os.WriteFile(bundle, append([]byte("flatpak\x00\x01\x00\x00ref\x00app/org.example.MyApp/x86_64/stable\x00"), make([]byte, 64)...), 0644)
+
+	for _, path := range []string{ref, bundle} {
+		id, err := FlatpakID(path)
+		if err != nil || id != "org.example.MyApp" {
+			t.Errorf("FlatpakID(%s) = %q, %v; want org.example.MyApp", filepath.Base(path), id, err)
+		}
+		if name := PackageName(path); name != "org.example.MyApp" {
+			t.Errorf("PackageName(%s) = %q", filepath.Base(path), name)
+		}
+	}
+
+	garbage := filepath.Join(dir, "other.flatpak")
+	os.WriteFile(garbage, []byte("not a bundle"), 0644)
+	if id, err := FlatpakID(garbage); err == nil {
+		t.Errorf("FlatpakID() of a non-bundle = %q, want an error", id)
+	}
+}
+
+func TestGetBatchInstallCmd(t *testing.T) {
+	cmd, err := GetBatchInstallCmd([]string{"/tmp/a.flatpakref"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cmd.Args, " "); got != "flatpak install --user -y --from /tmp/a.flatpakref" {
+		t.Errorf("flatpakref command = %q", got)
+	}
+
+	// Different types are chained, each running even if another fails
+	cmd, err = GetBatchInstallCmd([]string{"/tmp/a.deb", "/tmp/b.flatpak", "/tmp/c.deb", "/tmp/it's.flatpak"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "rc=0\n" +
+		"'sudo' 'apt-get' 'install' '-y' '/tmp/a.deb' '/tmp/c.deb' || rc=1\n" +
+		"'flatpak' 'install' '--user' '-y' '--bundle' '/tmp/b.flatpak' || rc=1\n" +
+		"'flatpak' 'install' '--user' '-y' '--bundle' '/tmp/it'\\''s.flatpak' || rc=1\n" +
+		"exit $rc\n"
+	if cmd.Args[0] != "sh" || cmd.Args[2] != want {
+		t.Errorf("mixed batch = %q, want sh -c %q", cmd.Args, want)
+	}
+
+	if _, err := GetBatchInstallCmd([]string{"/tmp/a.AppImage"}); err == nil {
+		t.Error("GetBatchInstallCmd() accepted an AppImage")
+	}
+}
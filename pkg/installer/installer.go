@@ -2,39 +2,79 @@ package installer
 
 import (
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"runtime"
+	"slices"
 	"strings"
 
 	"github.com/tim/autonomix-cli/pkg/github"
 	"github.com/tim/autonomix-cli/pkg/packages"
 	"github.com/tim/autonomix-cli/pkg/system"
+	"github.com/tim/autonomix-cli/pkg/verify"
 )
 
+// preferredType is the package type offered first when an app has no
+// preference of its own, see SetPreferredType.
+var preferredType packages.Type
+
+// SetPreferredType registers the global package type preference from the
+// config, e.g. packages.Flatpak to favour Flatpaks over the distro format.
+func SetPreferredType(t packages.Type) {
+	preferredType = t
+}
+
+// managerBinaries are the package managers a type is only offered with,
+// unless it is the preferred type.
+var managerBinaries = map[packages.Type]string{
+	packages.Flatpak: "flatpak",
+	packages.Snap:    "snap",
+}
+
 // GetCompatibleAssets returns a list of assets that are compatible with the current system.
+// Native packages come first, followed by AppImages, which run on any distribution,
+// then Flatpaks and Snaps when flatpak or snap is installed, and last
+// tarballs, zips and bare binaries.
 func GetCompatibleAssets(release *github.Release) ([]github.Asset, error) {
+	return CompatibleAssetsFor(release, AssetRules{})
+}
+
+// CompatibleAssetsFor is GetCompatibleAssets following an app's rules: assets
+// of the preferred package type come first, followed by the rest, and
+// include/exclude patterns filter the list. An asset matched by an include
+// pattern may omit its architecture. A remembered asset is moved to the front.
+func CompatibleAssetsFor(release *github.Release, rules AssetRules) ([]github.Asset, error) {
+	include, err := compilePatterns(rules.Include)
+	if err != nil {
+		return nil, err
+	}
+	exclude, err := compilePatterns(rules.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
 	sysType := system.GetSystemPreferredType()
-	if sysType == packages.Unknown {
-		return nil, fmt.Errorf("could not detect system package manager")
+	preferred := rules.Preferred
+	if preferred == "" {
+		preferred = preferredType
 	}
 
-	arch := runtime.GOARCH
-	// Map go arch to package arch strings commonly used
-	archKeywords := []string{arch}
-	if arch == "amd64" {
-		archKeywords = append(archKeywords, "x86_64", "x64")
-	} else if arch == "arm64" {
-		archKeywords = append(archKeywords, "aarch64", "armv8")
+	// Package types in the order they are offered
+	var ranked []packages.Type
+	for _, t := range []packages.Type{preferred, sysType, packages.AppImage, packages.Flatpak, packages.Snap, packages.Archive, packages.RawBinary} {
+		if t == "" || t == packages.Unknown || slices.Contains(ranked, t) {
+			continue
+		}
+		if bin, ok := managerBinaries[t]; ok && t != preferred {
+			if _, err := exec.LookPath(bin); err != nil {
+				continue
+			}
+		}
+		ranked = append(ranked, t)
 	}
-	
-	// Add universal/architecture-independent keywords
-	archKeywords = append(archKeywords, "all", "noarch", "any")
 
-	var compatible []github.Asset
+	arch := packages.HostArch()
+	byType := make(map[packages.Type][]github.Asset)
 	availableTypes := make(map[packages.Type]bool)
 	
 	for _, asset := range release.Assets {
@@ -43,40 +83,44 @@ func GetCompatibleAssets(release *github.Release) ([]github.Asset, error) {
 			availableTypes[detectedType] = true
 		}
 		
-		if detectedType != sysType {
+		if !slices.Contains(ranked, detectedType) {
+			continue
+		}
+		if matchAny(exclude, asset.Name) {
+			continue
+		}
+		included := len(include) > 0 && matchAny(include, asset.Name)
+		if len(include) > 0 && !included {
 			continue
 		}
 
-		nameLower := strings.ToLower(asset.Name)
-		
-		// Include if it matches arch, or if it seems universal (no arch keyword)
-		// But excluding if it matches wrong arch is safer.
-		// Let's include if it matches at least one keyword.
-		
-		matchedArch := false
-		for _, kw := range archKeywords {
-			if strings.Contains(nameLower, kw) {
-				matchedArch = true
-				break
-			}
+		// Include if it is built for this arch. Flatpak refs are
+		// architecture independent, and bundles often don't name their arch.
+		match := packages.MatchArch(asset.Name, arch)
+		matchedArch := match.Compatible()
+		if match == packages.ArchUnspecified && (detectedType == packages.Flatpak || included) {
+			matchedArch = true
 		}
 
 		if matchedArch {
-			compatible = append(compatible, asset)
+			byType[detectedType] = append(byType[detectedType], asset)
 		}
 	}
-	
-	// If no strict matches, do we want to search for "noarch" or "all"?
-	if len(compatible) == 0 {
-		for _, asset := range release.Assets {
-			detectedType := packages.DetectType(asset.Name)
-			if detectedType != sysType {
-				continue
-			}
-			// Check if it explicitly says another arch
-			// If not, maybe it's universal?
-			// This is heuristic.
-		}
+
+	var compatible []github.Asset
+	for _, t := range ranked {
+		compatible = append(compatible, byType[t]...)
+	}
+	if remembered, ok := FindRemembered(compatible, rules.Remembered); ok {
+		compatible = slices.DeleteFunc(compatible, func(a github.Asset) bool { return a.Name == remembered.Name })
+		compatible = append([]github.Asset{remembered}, compatible...)
+	}
+	if sysType == packages.Unknown && len(compatible) == 0 {
+		return nil, fmt.Errorf("could not detect system package manager")
+	}
+
+	if len(compatible) == 0 && len(include)+len(exclude) > 0 {
+		return nil, fmt.Errorf("no %s asset matches the app's include/exclude patterns", arch)
 	}
 
 	// If still no compatible assets, provide helpful error message
@@ -95,15 +139,7 @@ func GetCompatibleAssets(release *github.Release) ([]github.Asset, error) {
 // GetAllAssets returns all installable assets from a release, regardless of system compatibility.
 // Useful as a fallback when no compatible assets are found.
 func GetAllAssets(release *github.Release) []github.Asset {
-	arch := runtime.GOARCH
-	archKeywords := []string{arch}
-	if arch == "amd64" {
-		archKeywords = append(archKeywords, "x86_64", "x64")
-	} else if arch == "arm64" {
-		archKeywords = append(archKeywords, "aarch64", "armv8")
-	}
-	archKeywords = append(archKeywords, "all", "noarch", "any")
-	
+	arch := packages.HostArch()
 	var all []github.Asset
 	for _, asset := range release.Assets {
 		detectedType := packages.DetectType(asset.Name)
@@ -113,38 +149,17 @@ func GetAllAssets(release *github.Release) []github.Asset {
 		}
 		
 		// Filter by arch
-		nameLower := strings.ToLower(asset.Name)
-		matchedArch := false
-		for _, kw := range archKeywords {
-			if strings.Contains(nameLower, kw) {
-				matchedArch = true
-				break
-			}
-		}
-		
-		if matchedArch {
+		if packages.MatchArch(asset.Name, arch).Compatible() {
 			all = append(all, asset)
 		}
 	}
 	return all
 }
 
-// DownloadAsset downloads the specified asset
-func DownloadAsset(asset *github.Asset) (string, error) {
-	tempDir := os.TempDir()
-	fileName := asset.Name
-	downloadPath := filepath.Join(tempDir, fileName)
-
-	fmt.Printf("Downloading %s...\n", asset.BrowserDownloadURL)
-	if err := downloadFile(downloadPath, asset.BrowserDownloadURL); err != nil {
-		return "", fmt.Errorf("failed to download: %w", err)
-	}
-	
-	return downloadPath, nil
-}
-
 // DownloadUpdate finds and downloads the update, returning the path to the file.
-func DownloadUpdate(release *github.Release) (string, error) {
+// The download is verified against release's checksums and, following trust's
+// policy, its signatures.
+func DownloadUpdate(release *github.Release, trust verify.Trust) (string, error) {
 	assets, err := GetCompatibleAssets(release)
 	if err != nil {
 		return "", err
@@ -154,36 +169,178 @@ func DownloadUpdate(release *github.Release) (string, error) {
 	}
 	
 	// Default behavior: pick the first one
-	return DownloadAsset(&assets[0])
+	path, _, err := DownloadVerifiedAsset(release, &assets[0], trust, nil)
+	return path, err
+}
+
+// Package is a downloaded package file with the options to install it with.
+type Package struct {
+	Path string
+	// SnapClassic installs a snap with classic confinement.
+	SnapClassic bool
+	// BinaryGlob selects the executables to install from an archive.
+	BinaryGlob string
+	// BinDir overrides where user-level installs put executables.
+	BinDir string
 }
 
 // GetInstallCmd returns the exec.Cmd to install the package.
 // It does NOT set Stdin/Stdout/Stderr, the caller should do that or use tea.Exec
 func GetInstallCmd(path string) (*exec.Cmd, error) {
-	sysType := system.GetSystemPreferredType()
-	
-	switch sysType {
+	return GetBatchInstallCmd([]string{path})
+}
+
+// PackageName returns the name a package file installs under when the file
+// itself says so, e.g. the application ID of a flatpak or the name of a snap.
+// It returns "" for packages whose name can only be guessed.
+func PackageName(path string) string {
+	switch packages.DetectType(path) {
+	case packages.Flatpak:
+		id, _ := FlatpakID(path)
+		return id
+	case packages.Snap:
+		return SnapName(path)
+	}
+	return ""
+}
+
+// GetPackageInstallCmd is GetInstallCmd for a package with install options.
+func GetPackageInstallCmd(pkg Package) (*exec.Cmd, error) {
+	return GetPackagesInstallCmd([]Package{pkg})
+}
+
+// GetBatchInstallCmd returns a single exec.Cmd installing all packages at once,
+// so sudo only prompts for a password one time. The package manager is chosen
+// by the type of each file; packages of different types are installed one
+// type after the other. Packages for which IsUserInstall is true are installed
+// with InstallUserPackage instead.
+func GetBatchInstallCmd(paths []string) (*exec.Cmd, error) {
+	pkgs := make([]Package, len(paths))
+	for i, path := range paths {
+		pkgs[i] = Package{Path: path}
+	}
+	return GetPackagesInstallCmd(pkgs)
+}
+
+// GetPackagesInstallCmd is GetBatchInstallCmd for packages with install options.
+func GetPackagesInstallCmd(pkgs []Package) (*exec.Cmd, error) {
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages to install")
+	}
+
+	// Group the packages by type, keeping the order they were given in
+	var order []packages.Type
+	groups := make(map[packages.Type][]Package)
+	for _, pkg := range pkgs {
+		t := packages.DetectType(pkg.Path)
+		if _, ok := groups[t]; !ok {
+			order = append(order, t)
+		}
+		groups[t] = append(groups[t], pkg)
+	}
+
+	var commands [][]string
+	for _, t := range order {
+		cmds, err := installArgs(t, groups[t])
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmds...)
+	}
+	if len(commands) == 1 {
+		return exec.Command(commands[0][0], commands[0][1:]...), nil
+	}
+
+	// Run every command even if one fails, and fail if any of them did
+	var script strings.Builder
+	script.WriteString("rc=0\n")
+	for _, args := range commands {
+		quoted := make([]string, len(args))
+		for i, arg := range args {
+			quoted[i] = shellQuote(arg)
+		}
+		script.WriteString(strings.Join(quoted, " ") + " || rc=1\n")
+	}
+	script.WriteString("exit $rc\n")
+	return exec.Command("sh", "-c", script.String()), nil
+}
+
+// installArgs returns the commands installing pkgs, which are all of type t.
+func installArgs(t packages.Type, pkgs []Package) ([][]string, error) {
+	paths := make([]string, len(pkgs))
+	for i, pkg := range pkgs {
+		paths[i] = pkg.Path
+	}
+
+	switch t {
 	case packages.Deb:
 		// sudo apt-get install -y ./path
 		// Using relative path for apt sometimes requires ./
-		absPath, _ := filepath.Abs(path)
-		return exec.Command("sudo", "apt-get", "install", "-y", absPath), nil
+		args := []string{"sudo", "apt-get", "install", "-y"}
+		for _, path := range paths {
+			absPath, _ := filepath.Abs(path)
+			args = append(args, absPath)
+		}
+		return [][]string{args}, nil
 	case packages.Rpm:
-		return exec.Command("sudo", "rpm", "-Uvh", path), nil
+		return [][]string{append([]string{"sudo", "rpm", "-Uvh"}, paths...)}, nil
 	case packages.Pacman:
-		return exec.Command("sudo", "pacman", "-U", "--noconfirm", path), nil
+		return [][]string{append([]string{"sudo", "pacman", "-U", "--noconfirm"}, paths...)}, nil
+	case packages.Flatpak:
+		// flatpak takes one bundle or ref file per command
+		var cmds [][]string
+		for _, path := range paths {
+			source := "--bundle"
+			if strings.HasSuffix(strings.ToLower(path), ".flatpakref") {
+				source = "--from"
+			}
+			cmds = append(cmds, []string{"flatpak", "install", "--user", "-y", source, path})
+		}
+		return cmds, nil
+	case packages.Snap:
+		// Local snaps are unsigned, hence --dangerous. snapd only accepts
+		// --classic for a single snap, so each classic snap gets its own command.
+		var strict []string
+		var cmds [][]string
+		for _, pkg := range pkgs {
+			if pkg.SnapClassic {
+				cmds = append(cmds, []string{"sudo", "snap", "install", "--dangerous", "--classic", pkg.Path})
+			} else {
+				strict = append(strict, pkg.Path)
+			}
+		}
+		if len(strict) > 0 {
+			cmds = append([][]string{append([]string{"sudo", "snap", "install", "--dangerous"}, strict...)}, cmds...)
+		}
+		return cmds, nil
+	case packages.AppImage, packages.Archive, packages.RawBinary:
+		return nil, fmt.Errorf("%s is installed without a package manager", t)
 	default:
-		return nil, fmt.Errorf("unsupported install type: %s", sysType)
+		return nil, fmt.Errorf("unsupported install type: %s", t)
 	}
 }
 
-func InstallUpdate(release *github.Release) error {
-	path, err := DownloadUpdate(release)
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func InstallUpdate(release *github.Release, trust verify.Trust) error {
+	path, err := DownloadUpdate(release, trust)
 	if err != nil {
 		return err
 	}
 	defer os.Remove(path)
 
+	if IsUserInstall(path) {
+		// Name the install after the repository, like a tracked app
+		name := release.Name
+		if repo, _, ok := strings.Cut(release.HTMLURL, "/releases/"); ok {
+			name = repo[strings.LastIndex(repo, "/")+1:]
+		}
+		_, err := InstallUserPackage(Package{Path: path}, name, release.TagName)
+		return err
+	}
+
 	cmd, err := GetInstallCmd(path)
 	if err != nil {
 		return err
@@ -199,59 +356,18 @@ func InstallUpdate(release *github.Release) error {
 }
 
 func findMatchingAsset(assets []github.Asset, sysType packages.Type) (*github.Asset, error) {
-	arch := runtime.GOARCH
-	// Map go arch to package arch strings commonly used
-	archKeywords := []string{arch}
-	if arch == "amd64" {
-		archKeywords = append(archKeywords, "x86_64", "x64")
-	} else if arch == "arm64" {
-		archKeywords = append(archKeywords, "aarch64", "armv8")
-	}
-
-	// Add universal/architecture-independent keywords
-	archKeywords = append(archKeywords, "all", "noarch", "any")
-
+	arch := packages.HostArch()
 	for _, asset := range assets {
 		detectedType := packages.DetectType(asset.Name)
 		if detectedType != sysType {
 			continue
 		}
-
-		// Check arch
-		nameLower := strings.ToLower(asset.Name)
-		for _, kw := range archKeywords {
-			if strings.Contains(nameLower, kw) {
-				return &asset, nil
-			}
+		if packages.MatchArch(asset.Name, arch).Compatible() {
+			return &asset, nil
 		}
-		
-		// Fallback: if no arch info is in the name, but type matches, it might be universal or the only one.
-		// But risky. Let's look for one that doesn't contradict.
-		// Actually, let's just return the first match of the type if strict arch match fails, 
-		// but typically release assets have arch in name.
 	}
 
 	return nil, fmt.Errorf("no matching asset found for type %s and arch %s", sysType, arch)
 }
 
-func downloadFile(filepath string, url string) error {
-	resp, err := http.Get(url)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
-	}
-
-	out, err := os.Create(filepath)
-	if err != nil {
-		return err
-	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
-}
 
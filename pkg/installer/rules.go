@@ -0,0 +1,127 @@
+package installer
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/packages"
+)
+
+// AssetRules narrows down and orders the compatible assets of a release for
+// one app.
+type AssetRules struct {
+	// Preferred is the package type listed first. Empty falls back to
+	// SetPreferredType's.
+	Preferred packages.Type
+	// Include, if not empty, keeps only assets matching one of its patterns.
+	// Exclude drops assets matching any of its patterns. A pattern is a glob
+	// such as "*-musl.tar.gz", or a regular expression between slashes such
+	// as "/-(gnu|static)\.zip$/".
+	Include []string
+	Exclude []string
+	// Remembered is the pattern of an asset picked before, see
+	// RememberPattern. Matching assets are listed first.
+	Remembered string
+}
+
+// assetPattern is a compiled include or exclude pattern.
+type assetPattern struct {
+	glob string
+	re   *regexp.Regexp
+}
+
+func compilePattern(pattern string) (assetPattern, error) {
+	if len(pattern) > 2 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return assetPattern{}, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+		}
+		return assetPattern{re: re}, nil
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return assetPattern{}, fmt.Errorf("invalid asset pattern %q: %w", pattern, err)
+	}
+	return assetPattern{glob: pattern}, nil
+}
+
+func (p assetPattern) match(name string) bool {
+	if p.re != nil {
+		return p.re.MatchString(name)
+	}
+	ok, _ := path.Match(p.glob, name)
+	return ok
+}
+
+func compilePatterns(patterns []string) ([]assetPattern, error) {
+	var compiled []assetPattern
+	for _, pattern := range patterns {
+		p, err := compilePattern(pattern)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, p)
+	}
+	return compiled, nil
+}
+
+func matchAny(patterns []assetPattern, name string) bool {
+	for _, p := range patterns {
+		if p.match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAsset reports whether the asset name matches pattern, a glob or a
+// regular expression between slashes.
+func MatchAsset(pattern, name string) (bool, error) {
+	p, err := compilePattern(pattern)
+	if err != nil {
+		return false, err
+	}
+	return p.match(name), nil
+}
+
+// RememberPattern turns the name of an asset picked from release tag into a
+// glob matching the same asset in later releases, by replacing the version
+// with "*": "tool_1.2.0_linux_amd64.tar.gz" -> "tool_*_linux_amd64.tar.gz".
+func RememberPattern(name, tag string) string {
+	escape := strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`)
+	version := strings.TrimPrefix(strings.TrimPrefix(tag, "v"), "V")
+	if version == "" {
+		return escape.Replace(name)
+	}
+	// Try the tag as is first, so "v1.2.0" doesn't leave a stray "v"
+	for _, v := range []string{tag, version} {
+		if strings.Contains(name, v) {
+			parts := strings.Split(name, v)
+			for i := range parts {
+				parts[i] = escape.Replace(parts[i])
+			}
+			return strings.Join(parts, "*")
+		}
+	}
+	return escape.Replace(name)
+}
+
+// FindRemembered returns the asset matching the remembered pattern, if
+// exactly one does.
+func FindRemembered(assets []github.Asset, pattern string) (github.Asset, bool) {
+	if pattern == "" {
+		return github.Asset{}, false
+	}
+	var found []github.Asset
+	for _, a := range assets {
+		if ok, _ := MatchAsset(pattern, a.Name); ok {
+			found = append(found, a)
+		}
+	}
+	if len(found) != 1 {
+		return github.Asset{}, false
+	}
+	return found[0], true
+}
@@ -0,0 +1,118 @@
+package installer
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/tim/autonomix-cli/pkg/github"
+)
+
+func assetNames(assets []github.Asset) []string {
+	var names []string
+	for _, a := range assets {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+func TestCompatibleAssetsFor_Rules(t *testing.T) {
+	arch := runtime.GOARCH
+	release := &github.Release{
+		TagName: "v1.2.0",
+		Assets: []github.Asset{
+			{Name: "tool-1.2.0-linux-" + arch + "-gnu.tar.gz"},
+			{Name: "tool-1.2.0-linux-" + arch + "-musl.tar.gz"},
+			{Name: "tool-1.2.0-linux-" + arch + "-static.tar.gz"},
+			{Name: "tool-1.2.0-linux-" + arch + "-debug.tar.gz"},
+			{Name: "tool-1.2.0-linux-universal.zip"},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		rules AssetRules
+		want  []string
+	}{
+		{
+			name:  "exclude glob",
+			rules: AssetRules{Exclude: []string{"*-debug.tar.gz", "*-gnu.*"}},
+			want:  []string{"tool-1.2.0-linux-" + arch + "-musl.tar.gz", "tool-1.2.0-linux-" + arch + "-static.tar.gz"},
+		},
+		{
+			name:  "include regex",
+			rules: AssetRules{Include: []string{`/-(musl|static)\.tar\.gz$/`}},
+			want:  []string{"tool-1.2.0-linux-" + arch + "-musl.tar.gz", "tool-1.2.0-linux-" + arch + "-static.tar.gz"},
+		},
+		{
+			// Included assets need not name their architecture
+			name:  "include without arch",
+			rules: AssetRules{Include: []string{"*-universal.zip"}},
+			want:  []string{"tool-1.2.0-linux-universal.zip"},
+		},
+		{
+			name:  "remembered first",
+			rules: AssetRules{Exclude: []string{"*-debug*"}, Remembered: "tool-*-linux-" + arch + "-static.tar.gz"},
+			want: []string{
+				"tool-1.2.0-linux-" + arch + "-static.tar.gz",
+				"tool-1.2.0-linux-" + arch + "-gnu.tar.gz",
+				"tool-1.2.0-linux-" + arch + "-musl.tar.gz",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assets, err := CompatibleAssetsFor(release, tt.rules)
+			if err != nil {
+				t.Fatalf("CompatibleAssetsFor() error = %v", err)
+			}
+			got := assetNames(assets)
+			if len(got) != len(tt.want) {
+				t.Fatalf("CompatibleAssetsFor() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("CompatibleAssetsFor() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+
+	if _, err := CompatibleAssetsFor(release, AssetRules{Include: []string{"/(unclosed/"}}); err == nil {
+		t.Error("CompatibleAssetsFor() accepted an invalid regular expression")
+	}
+	if _, err := CompatibleAssetsFor(release, AssetRules{Include: []string{"*-windows.zip"}}); err == nil {
+		t.Error("CompatibleAssetsFor() found assets although no asset matches the rules")
+	}
+}
+
+func TestRememberPattern(t *testing.T) {
+	tests := []struct {
+		name, tag, want string
+	}{
+		{"tool_1.2.0_linux_amd64.tar.gz", "v1.2.0", "tool_*_linux_amd64.tar.gz"},
+		{"tool-v1.2.0-x86_64-unknown-linux-musl.tar.gz", "v1.2.0", "tool-*-x86_64-unknown-linux-musl.tar.gz"},
+		{"tool-linux-amd64", "v1.2.0", "tool-linux-amd64"},
+		{"odd[1].zip", "2.0", `odd\[1].zip`},
+	}
+	for _, tt := range tests {
+		got := RememberPattern(tt.name, tt.tag)
+		if got != tt.want {
+			t.Errorf("RememberPattern(%q, %q) = %q, want %q", tt.name, tt.tag, got, tt.want)
+		}
+		if ok, err := MatchAsset(got, tt.name); !ok || err != nil {
+			t.Errorf("pattern %q does not match %q: %v", got, tt.name, err)
+		}
+	}
+
+	next := []github.Asset{
+		{Name: "tool_1.3.0_linux_amd64.tar.gz"},
+		{Name: "tool_1.3.0_linux_arm64.tar.gz"},
+	}
+	if a, ok := FindRemembered(next, "tool_*_linux_amd64.tar.gz"); !ok || a.Name != "tool_1.3.0_linux_amd64.tar.gz" {
+		t.Errorf("FindRemembered() = %q, %v", a.Name, ok)
+	}
+	if _, ok := FindRemembered(next, "tool_*_linux_*.tar.gz"); ok {
+		t.Error("FindRemembered() picked one of several matching assets")
+	}
+}
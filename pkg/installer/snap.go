@@ -0,0 +1,27 @@
+package installer
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SnapName returns the name of the snap in the .snap file at path. It is read
+// from meta/snap.yaml when unsquashfs is available, and otherwise taken from
+// the "<name>_<version>_<arch>.snap" file name convention.
+func SnapName(path string) string {
+	if out, err := exec.Command("unsquashfs", "-cat", path, "meta/snap.yaml").Output(); err == nil {
+		var meta struct {
+			Name string `yaml:"name"`
+		}
+		if yaml.Unmarshal(out, &meta) == nil && meta.Name != "" {
+			return meta.Name
+		}
+	}
+	base := filepath.Base(path)
+	base = base[:len(base)-len(filepath.Ext(base))]
+	name, _, _ := strings.Cut(base, "_")
+	return strings.ToLower(name)
+}
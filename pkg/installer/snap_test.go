@@ -0,0 +1,47 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSnapName(t *testing.T) {
+	// Not a squashfs image, so the name comes from the file name
+	path := filepath.Join(t.TempDir(), "My-Tool_1.2.3_amd64.snap")
+	os.WriteFile(path, []byte("not squashfs"), 0644)
+	if name := SnapName(path); name != "my-tool" {
+		t.Errorf("SnapName() = %q, want my-tool", name)
+	}
+}
+
+func TestGetPackagesInstallCmd_Snap(t *testing.T) {
+	cmd, err := GetPackageInstallCmd(Package{Path: "/tmp/a.snap", SnapClassic: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"sudo", "snap", "install", "--dangerous", "--classic", "/tmp/a.snap"}
+	if len(cmd.Args) != len(want) {
+		t.Fatalf("command = %q, want %q", cmd.Args, want)
+	}
+	for i := range want {
+		if cmd.Args[i] != want[i] {
+			t.Fatalf("command = %q, want %q", cmd.Args, want)
+		}
+	}
+
+	// --classic must not leak onto strictly confined snaps
+	// and each classic snap needs its own command
+	cmd, err = GetPackagesInstallCmd([]Package{{Path: "/tmp/a.snap"}, {Path: "/tmp/b.snap", SnapClassic: true}, {Path: "/tmp/c.snap"}, {Path: "/tmp/d.snap", SnapClassic: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	script := "rc=0\n" +
+		"'sudo' 'snap' 'install' '--dangerous' '/tmp/a.snap' '/tmp/c.snap' || rc=1\n" +
+		"'sudo' 'snap' 'install' '--dangerous' '--classic' '/tmp/b.snap' || rc=1\n" +
+		"'sudo' 'snap' 'install' '--dangerous' '--classic' '/tmp/d.snap' || rc=1\n" +
+		"exit $rc\n"
+	if cmd.Args[0] != "sh" || cmd.Args[2] != script {
+		t.Errorf("mixed confinement = %q, want sh -c %q", cmd.Args, script)
+	}
+}
@@ -0,0 +1,45 @@
+package installer
+
+import (
+	"fmt"
+	"os/exec"
+
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/system"
+)
+
+// GetUninstallCmd returns the exec.Cmd removing the package name through the
+// package manager that installed it. Like GetInstallCmd it does NOT set
+// Stdin/Stdout/Stderr.
+func GetUninstallCmd(pkgType packages.Type, name string) (*exec.Cmd, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no package name to uninstall")
+	}
+
+	switch pkgType {
+	case packages.Deb:
+		return exec.Command("sudo", "apt-get", "remove", "-y", name), nil
+	case packages.Rpm:
+		return exec.Command("sudo", "rpm", "-e", name), nil
+	case packages.Pacman:
+		return exec.Command("sudo", "pacman", "-R", "--noconfirm", name), nil
+	case packages.Flatpak:
+		return exec.Command("flatpak", "uninstall", "--user", "-y", name), nil
+	case packages.Snap:
+		return exec.Command("sudo", "snap", "remove", name), nil
+	case packages.AppImage, packages.Archive, packages.RawBinary:
+		rec, ok := system.LoadLocalInstall(name)
+		if !ok {
+			return nil, fmt.Errorf("no install record for %s", name)
+		}
+		record, err := system.LocalRecordPath(name)
+		if err != nil {
+			return nil, err
+		}
+		return exec.Command("rm", append([]string{"-f"}, append(rec.Files, record)...)...), nil
+	case packages.Unknown:
+		return nil, fmt.Errorf("%s was not installed by a package manager, remove it manually", name)
+	default:
+		return nil, fmt.Errorf("unsupported uninstall type: %s", pkgType)
+	}
+}
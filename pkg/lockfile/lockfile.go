@@ -0,0 +1,141 @@
+// Package lockfile reads and writes autonomix.lock, which records the exact
+// release assets installed on a machine so others can reproduce them with
+// "autonomix-cli install --locked".
+package lockfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/tim/autonomix-cli/pkg/packages"
+)
+
+// DefaultPath is the lockfile used when none is given, in the working
+// directory so it can be committed next to a manifest.
+const DefaultPath = "autonomix.lock"
+
+// Version is the lockfile format this build writes.
+const Version = 1
+
+// Lockfile lists the locked assets of every app, per platform.
+type Lockfile struct {
+	Version int     `json:"lock_version"`
+	Apps    []Entry `json:"apps"`
+}
+
+// Entry is the asset installed for one app on one platform.
+type Entry struct {
+	Name string `json:"name"`
+	Repo string `json:"repo"`
+	// Platform is the OS and architecture the asset is for, see Platform.
+	Platform string `json:"platform"`
+	Tag      string `json:"tag"`
+	Asset    string `json:"asset"`
+	URL      string `json:"url"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256"`
+}
+
+// Platform returns the platform of this machine, e.g. "linux/amd64" or
+// "linux/armv7". Machines only install entries of their own platform.
+func Platform() string {
+	return runtime.GOOS + "/" + packages.HostArch().String()
+}
+
+// Load reads the lockfile at path.
+func Load(path string) (*Lockfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l Lockfile
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if l.Version > Version {
+		return nil, fmt.Errorf("%s: lock version %d is newer than this autonomix supports (%d)", path, l.Version, Version)
+	}
+	return &l, nil
+}
+
+// Save writes l to path through a temporary file, sorted so that diffs
+// between machines stay small.
+func (l *Lockfile) Save(path string) error {
+	l.Version = Version
+	sort.Slice(l.Apps, func(i, j int) bool {
+		a, b := l.Apps[i], l.Apps[j]
+		if !strings.EqualFold(a.Repo, b.Repo) {
+			return strings.ToLower(a.Repo) < strings.ToLower(b.Repo)
+		}
+		return a.Platform < b.Platform
+	})
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".autonomix-lock-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Find returns the entry of repo for platform.
+func (l *Lockfile) Find(repo, platform string) (Entry, bool) {
+	for _, e := range l.Apps {
+		if strings.EqualFold(e.Repo, repo) && e.Platform == platform {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// ForPlatform returns the entries for platform.
+func (l *Lockfile) ForPlatform(platform string) []Entry {
+	var entries []Entry
+	for _, e := range l.Apps {
+		if e.Platform == platform {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Set adds e, replacing the entry of the same repository and platform.
+func (l *Lockfile) Set(e Entry) {
+	for i := range l.Apps {
+		if strings.EqualFold(l.Apps[i].Repo, e.Repo) && l.Apps[i].Platform == e.Platform {
+			l.Apps[i] = e
+			return
+		}
+	}
+	l.Apps = append(l.Apps, e)
+}
+
+// Remove drops the entry of repo for platform.
+func (l *Lockfile) Remove(repo, platform string) {
+	for i := range l.Apps {
+		if strings.EqualFold(l.Apps[i].Repo, repo) && l.Apps[i].Platform == platform {
+			l.Apps = append(l.Apps[:i], l.Apps[i+1:]...)
+			return
+		}
+	}
+}
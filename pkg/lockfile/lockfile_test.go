@@ -0,0 +1,51 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPath)
+	l := &Lockfile{}
+	l.Set(Entry{Name: "rg", Repo: "https://github.com/BurntSushi/ripgrep", Platform: "linux/arm64", Tag: "14.1.0"})
+	l.Set(Entry{Name: "fzf", Repo: "https://github.com/junegunn/fzf", Platform: "linux/amd64", Tag: "v0.54.0"})
+	l.Set(Entry{Name: "rg", Repo: "https://github.com/BurntSushi/ripgrep", Platform: "linux/amd64", Tag: "14.1.0"})
+	// Replaces the entry of the same repository and platform
+	l.Set(Entry{Name: "fzf", Repo: "https://github.com/junegunn/fzf", Platform: "linux/amd64", Tag: "v0.55.0", SHA256: "abc"})
+
+	if err := l.Save(path); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	got, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if got.Version != Version || len(got.Apps) != 3 {
+		t.Fatalf("Load() = %+v", got)
+	}
+	// Sorted by repository, then platform
+	if got.Apps[0].Platform != "linux/amd64" || got.Apps[1].Platform != "linux/arm64" || got.Apps[2].Name != "fzf" {
+		t.Errorf("entries not sorted: %+v", got.Apps)
+	}
+	if e, ok := got.Find("https://github.com/JuneGunn/fzf", "linux/amd64"); !ok || e.Tag != "v0.55.0" || e.SHA256 != "abc" {
+		t.Errorf("Find() = %+v, %v", e, ok)
+	}
+	if n := len(got.ForPlatform("linux/arm64")); n != 1 {
+		t.Errorf("ForPlatform() = %d entries, want 1", n)
+	}
+
+	got.Remove("https://github.com/BurntSushi/ripgrep", "linux/arm64")
+	if _, ok := got.Find("https://github.com/BurntSushi/ripgrep", "linux/arm64"); ok {
+		t.Error("Remove() kept the entry")
+	}
+}
+
+func TestLoad_NewerVersion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultPath)
+	os.WriteFile(path, []byte(`{"lock_version": 99, "apps": []}`), 0644)
+	if _, err := Load(path); err == nil {
+		t.Error("Load() accepted a newer lock version")
+	}
+}
@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/installer"
+	"github.com/tim/autonomix-cli/pkg/lockfile"
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+// getReleaseByTag looks up a release, overridden in tests.
+var getReleaseByTag = github.GetReleaseByTag
+
+// LockEntry returns the lockfile entry for the installed release of app,
+// from the tag, asset and SHA-256 recorded when autonomix installed it. The
+// release is looked up for the asset's URL and size.
+func LockEntry(app config.App) (lockfile.Entry, error) {
+	if app.Version == "" {
+		return lockfile.Entry{}, fmt.Errorf("%s is not installed", app.Name)
+	}
+	// Package managers report "1.2.3-1" for the tag "v1.2.3"
+	if app.InstalledTag == "" || app.InstalledSHA256 == "" || !version.Equal(app.Version, app.InstalledTag) {
+		return lockfile.Entry{}, fmt.Errorf("%s %s was not installed by autonomix; reinstall it to lock it", app.Name, app.Version)
+	}
+	rel, err := getReleaseByTag(app.RepoURL, app.InstalledTag)
+	if err != nil {
+		return lockfile.Entry{}, fmt.Errorf("release %s: %w", app.InstalledTag, err)
+	}
+	for _, asset := range rel.Assets {
+		if asset.Name != app.InstalledAsset {
+			continue
+		}
+		return lockfile.Entry{
+			Name:     app.Name,
+			Repo:     app.RepoURL,
+			Platform: lockfile.Platform(),
+			Tag:      rel.TagName,
+			Asset:    asset.Name,
+			URL:      asset.BrowserDownloadURL,
+			Size:     int64(asset.Size),
+			SHA256:   app.InstalledSHA256,
+		}, nil
+	}
+	return lockfile.Entry{}, fmt.Errorf("release %s no longer has %s", app.InstalledTag, app.InstalledAsset)
+}
+
+// PrepareLocked downloads the asset entry locks for app and fails unless
+// it hashes to the locked SHA-256. Apps already at the locked tag are
+// skipped unless force is set.
+func PrepareLocked(app config.App, entry lockfile.Entry, force bool, progress installer.ProgressFunc) *UpdatePlan {
+	res := UpdateResult{App: app, From: app.Version, To: entry.Tag, exact: true}
+	plan := &UpdatePlan{}
+	fail := func(err error) *UpdatePlan {
+		res.Outcome = UpdateFailed
+		res.Reason = err.Error()
+		plan.Results = append(plan.Results, res)
+		return plan
+	}
+
+	if !force && version.Equal(app.Version, entry.Tag) {
+		res.Outcome = UpdateSkipped
+		res.Reason = fmt.Sprintf("already at %s", entry.Tag)
+		plan.Results = append(plan.Results, res)
+		return plan
+	}
+	if entry.SHA256 == "" {
+		return fail(fmt.Errorf("lockfile has no sha256 for %s", entry.Asset))
+	}
+
+	// The release supplies the checksum and signature files to verify with
+	rel, err := getReleaseByTag(app.RepoURL, entry.Tag)
+	if err != nil {
+		return fail(fmt.Errorf("release %s: %w", entry.Tag, err))
+	}
+	var asset *github.Asset
+	for i := range rel.Assets {
+		if rel.Assets[i].Name == entry.Asset {
+			asset = &rel.Assets[i]
+		}
+	}
+	if asset == nil {
+		return fail(fmt.Errorf("release %s no longer has %s", entry.Tag, entry.Asset))
+	}
+	locked := *asset
+	locked.BrowserDownloadURL = entry.URL
+
+	plan.Results = append(plan.Results, fetch(res, rel, locked, entry.SHA256, progress))
+	return plan
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+)
+
+func TestLockEntry_RecordedInstall(t *testing.T) {
+	old := getReleaseByTag
+	t.Cleanup(func() { getReleaseByTag = old })
+	getReleaseByTag = func(repoURL, tag string) (*github.Release, error) {
+		if tag != "v1.2.3" {
+			return nil, fmt.Errorf("unexpected tag %s", tag)
+		}
+		return &github.Release{TagName: tag, Assets: []github.Asset{
+			{Name: "app_1.2.3_amd64.rpm", BrowserDownloadURL: "https://example.com/app.rpm", Size: 20},
+			{Name: "app_1.2.3_amd64.deb", BrowserDownloadURL: "https://example.com/app.deb", Size: 10},
+		}}, nil
+	}
+
+	// The package manager reports a revision and a newer release is out
+	app := config.App{
+		Name:            "app",
+		RepoURL:         "https://github.com/owner/app",
+		Version:         "1.2.3-1",
+		Latest:          "v1.3.0",
+		InstalledTag:    "v1.2.3",
+		InstalledAsset:  "app_1.2.3_amd64.deb",
+		InstalledSHA256: "abc123",
+	}
+	entry, err := LockEntry(app)
+	if err != nil {
+		t.Fatalf("LockEntry() error = %v", err)
+	}
+	if entry.Tag != "v1.2.3" || entry.Asset != "app_1.2.3_amd64.deb" || entry.SHA256 != "abc123" ||
+		entry.URL != "https://example.com/app.deb" || entry.Size != 10 {
+		t.Errorf("LockEntry() = %+v, want the recorded v1.2.3 deb", entry)
+	}
+
+	// A version installed outside autonomix has no matching record
+	app.Version = "1.3.0-1"
+	if _, err := LockEntry(app); err == nil {
+		t.Error("LockEntry() of an unrecorded version succeeded")
+	}
+}
@@ -1,14 +1,22 @@
 package manager
 
 import (
+	"errors"
 	"fmt"
+	"os/exec"
 	"strings"
 
 	"github.com/tim/autonomix-cli/config"
 	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/installer"
+	"github.com/tim/autonomix-cli/pkg/packages"
 	"github.com/tim/autonomix-cli/pkg/system"
 )
 
+// SelfRepoURL is the repository of autonomix itself, which tracks its own
+// updates. It is never pruned.
+const SelfRepoURL = "https://github.com/sgtapple/autonomix-cli"
+
 // AddResult contains the info about the added app
 type AddResult struct {
 	App     config.App
@@ -17,17 +25,7 @@ type AddResult struct {
 
 // AddApp handles the logic of adding a new repository to the configuration
 func AddApp(cfg *config.Config, repoURL string) (*AddResult, error) {
-	// Clean the URL to bare repo URL
-	// E.g. https://github.com/owner/repo/releases -> https://github.com/owner/repo
-	if strings.Contains(repoURL, "github.com") {
-		parts := strings.Split(repoURL, "github.com/")
-		if len(parts) == 2 {
-			pathParts := strings.Split(strings.Trim(parts[1], "/"), "/")
-			if len(pathParts) >= 2 {
-				repoURL = "https://github.com/" + pathParts[0] + "/" + pathParts[1]
-			}
-		}
-	}
+	repoURL = github.CleanRepoURL(repoURL)
 
 	// Check if already exists
 	for _, app := range cfg.Apps {
@@ -60,18 +58,219 @@ func AddApp(cfg *config.Config, repoURL string) (*AddResult, error) {
 	}
 
 	// Check if installed locally
-	if ver, _, installed := system.CheckInstalled(newApp.Name); installed {
-		newApp.Version = ver
-	} else if repoName != "" && repoName != newApp.Name {
-		if ver, _, installed := system.CheckInstalled(repoName); installed {
-			newApp.Version = ver
-		}
+	if inst, installed := DetectInstalled(newApp); installed {
+		RecordInstallation(&newApp, inst)
 	}
 
-	cfg.Apps = append(cfg.Apps, newApp)
-	if err := config.Save(cfg); err != nil {
-		return nil, fmt.Errorf("failed to save config: %w", err)
+	// Another process may have added the repository during the fetch
+	var existing *config.App
+	err = UpdateConfig(cfg, func(cfg *config.Config) error {
+		for _, app := range cfg.Apps {
+			if strings.EqualFold(app.RepoURL, repoURL) {
+				existing = &app
+				return fmt.Errorf("repository already tracked")
+			}
+		}
+		cfg.Apps = append(cfg.Apps, newApp)
+		return nil
+	})
+	if existing != nil {
+		return &AddResult{App: *existing, Created: false}, err
+	}
+	if err != nil {
+		return nil, err
 	}
 
 	return &AddResult{App: newApp, Created: true}, nil
 }
+
+// LoadConfig loads the config and registers its global settings: the GitHub
+// token, the preferred package type and the bin directory. A config that had
+// to be quarantined is replaced by an empty one, which is returned together
+// with the *config.QuarantineError so the caller can report it.
+func LoadConfig() (*config.Config, error) {
+	cfg, err := config.Load()
+	var quarantined *config.QuarantineError
+	if err != nil && !errors.As(err, &quarantined) {
+		return nil, err
+	}
+	github.SetConfigToken(cfg.GitHubToken)
+	installer.SetPreferredType(packages.Type(cfg.PreferredType))
+	system.SetBinDir(cfg.BinDir)
+	return cfg, err
+}
+
+// UpdateConfig changes the config file through config.Update and replaces
+// cfg with the result, which includes changes made by other processes. fn
+// works on the freshly loaded config, so it must look apps up again rather
+// than use indexes into cfg. Errors from fn are returned unchanged.
+func UpdateConfig(cfg *config.Config, fn func(cfg *config.Config) error) error {
+	var fnErr error
+	fresh, err := config.Update(func(cfg *config.Config) error {
+		fnErr = fn(cfg)
+		return fnErr
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	*cfg = *fresh
+	return nil
+}
+
+// updateApp applies fn to the app matching query in the config file and
+// returns the changed app.
+func updateApp(cfg *config.Config, query string, fn func(app *config.App) error) (*config.App, error) {
+	var app config.App
+	err := UpdateConfig(cfg, func(cfg *config.Config) error {
+		idx, err := FindApp(cfg, query)
+		if err != nil {
+			return err
+		}
+		if err := fn(&cfg.Apps[idx]); err != nil {
+			return err
+		}
+		app = cfg.Apps[idx]
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// DetectInstalled looks for the app on the system, first by its recorded
+// package, then by app name and then by repository name.
+func DetectInstalled(app config.App) (system.Installation, bool) {
+	// A recorded package name, such as a flatpak application ID, is exact
+	if app.PackageName != "" && app.PackageType != "" {
+		if inst, ok := system.FindPackage(packages.Type(app.PackageType), app.PackageName); ok {
+			return inst, true
+		}
+	}
+	if inst, ok := system.FindInstalled(app.Name); ok {
+		return inst, true
+	}
+	repoName := app.RepoURL[strings.LastIndex(app.RepoURL, "/")+1:]
+	if repoName != "" && repoName != app.Name {
+		if inst, ok := system.FindInstalled(repoName); ok {
+			return inst, true
+		}
+	}
+	return system.Installation{Type: packages.Unknown}, false
+}
+
+// RecordInstallation stores the detected version and package on app.
+func RecordInstallation(app *config.App, inst system.Installation) {
+	app.Version = inst.Version
+	if inst.Type == packages.Unknown {
+		app.PackageName, app.PackageType = "", ""
+		return
+	}
+	app.PackageName = inst.Name
+	app.PackageType = string(inst.Type)
+}
+
+// RecordInstalledAsset stores the release asset autonomix installed for
+// app and its SHA-256, which LockEntry pins.
+func RecordInstalledAsset(app *config.App, tag, asset, sum string) {
+	app.InstalledTag = tag
+	app.InstalledAsset = asset
+	app.InstalledSHA256 = sum
+}
+
+// FindApp returns the index of the tracked app matching query, which may be
+// the app name, the repository name or the repository URL (case-insensitive).
+func FindApp(cfg *config.Config, query string) (int, error) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), "/")
+	for i, app := range cfg.Apps {
+		if strings.EqualFold(app.Name, query) || strings.EqualFold(app.RepoURL, query) {
+			return i, nil
+		}
+	}
+	// Fall back to the repository name, e.g. "autonomix-cli" or "owner/repo"
+	for i, app := range cfg.Apps {
+		if strings.HasSuffix(strings.ToLower(app.RepoURL), "/"+strings.ToLower(query)) {
+			return i, nil
+		}
+	}
+	return -1, fmt.Errorf("no tracked app matches %q", query)
+}
+
+// PinApp holds the app matching query at version, or at its installed
+// version when version is empty.
+func PinApp(cfg *config.Config, query, version string) (*config.App, error) {
+	return updateApp(cfg, query, func(app *config.App) error {
+		if version == "" {
+			version = app.Version
+		}
+		if version == "" {
+			return fmt.Errorf("%s is not installed, specify the version to hold", app.Name)
+		}
+		app.Pinned = true
+		app.HoldVersion = version
+		return nil
+	})
+}
+
+// UnpinApp releases the hold on the app matching query.
+func UnpinApp(cfg *config.Config, query string) (*config.App, error) {
+	return updateApp(cfg, query, func(app *config.App) error {
+		app.Pinned = false
+		app.HoldVersion = ""
+		return nil
+	})
+}
+
+// UninstallCmd returns the command removing app from the system. The package
+// recorded at install time is preferred; otherwise the app is detected again.
+func UninstallCmd(app config.App) (*exec.Cmd, error) {
+	pkgType, name := packages.Type(app.PackageType), app.PackageName
+	if name == "" {
+		inst, ok := DetectInstalled(app)
+		if !ok {
+			return nil, fmt.Errorf("%s does not appear to be installed", app.Name)
+		}
+		pkgType, name = inst.Type, inst.Name
+	}
+	return installer.GetUninstallCmd(pkgType, name)
+}
+
+// RemoveApp stops tracking the app matching query. It does not uninstall it.
+func RemoveApp(cfg *config.Config, query string) (*config.App, error) {
+	var app config.App
+	err := UpdateConfig(cfg, func(cfg *config.Config) error {
+		idx, err := FindApp(cfg, query)
+		if err != nil {
+			return err
+		}
+		app = cfg.Apps[idx]
+		cfg.Apps = append(cfg.Apps[:idx], cfg.Apps[idx+1:]...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// ResolveRelease returns the release of app tagged tag, or the newest
+// release on the app's channel when tag is empty. A tag given without its
+// "v" prefix is retried with one.
+func ResolveRelease(app config.App, tag string, refresh bool) (*github.Release, error) {
+	if tag == "" {
+		return github.FetchChannelRelease(app.RepoURL, app.Channel, refresh)
+	}
+	rel, err := github.GetReleaseByTag(app.RepoURL, tag)
+	if err != nil && !strings.HasPrefix(tag, "v") {
+		if vRel, vErr := github.GetReleaseByTag(app.RepoURL, "v"+tag); vErr == nil {
+			return vRel, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("release %s: %w", tag, err)
+	}
+	return rel, nil
+}
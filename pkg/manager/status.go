@@ -0,0 +1,64 @@
+package manager
+
+import (
+	"time"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+// Status values describe a tracked app at a glance.
+const (
+	StatusNotInstalled    = "not installed"
+	StatusHeld            = "held"
+	StatusUpToDate        = "up to date"
+	StatusUpdateAvailable = "update available"
+	StatusAhead           = "ahead of release"
+	StatusUnknown         = "unknown"
+)
+
+// Status summarizes app's installed version against its latest release.
+func Status(app config.App) string {
+	switch {
+	case app.Version == "":
+		return StatusNotInstalled
+	case app.Pinned:
+		return StatusHeld
+	}
+	switch version.Check(app.Version, app.Latest) {
+	case version.UpToDate:
+		return StatusUpToDate
+	case version.UpdateAvailable:
+		return StatusUpdateAvailable
+	case version.Ahead:
+		return StatusAhead
+	default:
+		return StatusUnknown
+	}
+}
+
+// RecordCheck stores the outcome of an update check on app: the latest tag
+// and check time on success, the error otherwise.
+func RecordCheck(app *config.App, rel *github.Release, err error) {
+	if err != nil {
+		app.LastError = err.Error()
+		return
+	}
+	app.Latest = rel.TagName
+	app.LastChecked = time.Now().UTC().Format(time.RFC3339)
+	app.LastError = ""
+}
+
+// CopyCheck copies what RecordCheck stored on checked to the app with the
+// same repository in cfg, to save a check made on an older copy of the
+// config. Apps removed meanwhile are skipped.
+func CopyCheck(cfg *config.Config, checked config.App) {
+	for i := range cfg.Apps {
+		if cfg.Apps[i].RepoURL == checked.RepoURL {
+			cfg.Apps[i].Latest = checked.Latest
+			cfg.Apps[i].LastChecked = checked.LastChecked
+			cfg.Apps[i].LastError = checked.LastError
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/installer"
+	"github.com/tim/autonomix-cli/pkg/manifest"
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+// SyncAction is what a sync does with one app.
+type SyncAction string
+
+const (
+	SyncKeep      SyncAction = "keep"
+	SyncInstall   SyncAction = "install"
+	SyncUpgrade   SyncAction = "upgrade"
+	SyncDowngrade SyncAction = "downgrade"
+	SyncSkip      SyncAction = "skip"
+	SyncFail      SyncAction = "fail"
+	// SyncUntrack and SyncUninstall remove apps the manifest does not list.
+	SyncUntrack   SyncAction = "untrack"
+	SyncUninstall SyncAction = "uninstall"
+)
+
+// SyncOptions control what PlanSync does with apps missing from the manifest.
+type SyncOptions struct {
+	// Prune untracks tracked apps that the manifest does not list, except
+	// autonomix itself.
+	Prune bool
+	// Uninstall also uninstalls pruned apps.
+	Uninstall bool
+}
+
+// SyncStep is the planned change to one app.
+type SyncStep struct {
+	// App is the app with the manifest's settings applied.
+	App    config.App
+	Action SyncAction
+	// New apps are not tracked yet.
+	New bool
+	// Release is the release to install for install, upgrade and downgrade.
+	Release *github.Release
+	Reason  string
+
+	entry manifest.App
+}
+
+// SyncPlan is the list of changes that make the config match a manifest.
+type SyncPlan struct {
+	Steps []SyncStep
+}
+
+// PlanSync compares the tracked apps with m and decides, app by app, what
+// to install. An installed version satisfying the app's constraint is kept;
+// without a constraint the app is kept up to date with its channel. Held
+// apps are never changed. Nothing is changed by planning.
+func PlanSync(cfg *config.Config, m *manifest.Manifest, opts SyncOptions) *SyncPlan {
+	plan := &SyncPlan{}
+	listed := map[string]bool{}
+	for _, entry := range m.Apps {
+		listed[strings.ToLower(entry.Repo)] = true
+		plan.Steps = append(plan.Steps, planSyncStep(cfg, entry))
+	}
+
+	if opts.Prune {
+		action := SyncUntrack
+		if opts.Uninstall {
+			action = SyncUninstall
+		}
+		for _, app := range cfg.Apps {
+			if listed[strings.ToLower(app.RepoURL)] || strings.EqualFold(app.RepoURL, SelfRepoURL) {
+				continue
+			}
+			step := SyncStep{App: app, Action: action, Reason: "not in the manifest"}
+			if action == SyncUninstall && app.Version == "" {
+				step.Action = SyncUntrack
+			}
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+	return plan
+}
+
+func planSyncStep(cfg *config.Config, entry manifest.App) SyncStep {
+	step := SyncStep{entry: entry}
+	if idx, err := FindApp(cfg, entry.Repo); err == nil {
+		step.App = cfg.Apps[idx]
+	} else {
+		step.New = true
+		step.App = config.App{Name: entry.DefaultName()}
+	}
+	entry.Apply(&step.App)
+	if step.New {
+		if inst, installed := DetectInstalled(step.App); installed {
+			RecordInstallation(&step.App, inst)
+		}
+	}
+
+	app := step.App
+	constraint := entry.Constraint()
+	if app.Version != "" && !constraint.IsAny() && constraint.Check(app.Version) {
+		step.Action = SyncKeep
+		step.Reason = fmt.Sprintf("%s satisfies %s", app.Version, constraint)
+		return step
+	}
+
+	rel, err := syncRelease(app, constraint)
+	if err != nil {
+		step.Action = SyncFail
+		step.Reason = err.Error()
+		return step
+	}
+	step.App.Latest = rel.TagName
+
+	switch {
+	case app.Version == "":
+		step.Action = SyncInstall
+		step.Reason = "-> " + rel.TagName
+	default:
+		switch version.Check(app.Version, rel.TagName) {
+		case version.UpToDate:
+			step.Action = SyncKeep
+			step.Reason = app.Version + " is up to date"
+		case version.UpdateAvailable:
+			step.Action = SyncUpgrade
+			step.Reason = app.Version + " -> " + rel.TagName
+		case version.Ahead:
+			if constraint.IsAny() {
+				step.Action = SyncKeep
+				step.Reason = app.Version + " is ahead of " + rel.TagName
+				break
+			}
+			step.Action = SyncDowngrade
+			step.Reason = app.Version + " -> " + rel.TagName
+		default:
+			step.Action = SyncSkip
+			step.Reason = fmt.Sprintf("cannot compare installed version %q with %s", app.Version, rel.TagName)
+			return step
+		}
+	}
+
+	if app.Pinned && step.Action != SyncKeep {
+		step.Action = SyncSkip
+		step.Reason = fmt.Sprintf("held at %s, wanted %s", app.HoldVersion, rel.TagName)
+		return step
+	}
+	if step.Action != SyncKeep {
+		step.Release = rel
+	}
+	return step
+}
+
+// syncRelease returns the newest release of app on its channel that
+// satisfies constraint.
+func syncRelease(app config.App, constraint version.Constraint) (*github.Release, error) {
+	if constraint.IsAny() {
+		return github.FetchChannelRelease(app.RepoURL, app.Channel, true)
+	}
+	rel, err := github.FindChannelRelease(app.RepoURL, app.Channel, true, func(rel *github.Release) bool {
+		return constraint.Check(rel.TagName)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("release satisfying %s: %w", constraint, err)
+	}
+	return rel, nil
+}
+
+// Configure tracks the new apps of the plan and applies the manifest's
+// settings to the listed ones. Call it inside UpdateConfig.
+func (p *SyncPlan) Configure(cfg *config.Config) {
+	for _, step := range p.Steps {
+		if step.Action == SyncUntrack || step.Action == SyncUninstall {
+			continue
+		}
+		idx, err := FindApp(cfg, step.entry.Repo)
+		if err != nil {
+			cfg.Apps = append(cfg.Apps, step.App)
+			continue
+		}
+		step.entry.Apply(&cfg.Apps[idx])
+	}
+}
+
+// PrepareInstalls downloads the packages of every install, upgrade and
+// downgrade in the plan, like PrepareInstall.
+func (p *SyncPlan) PrepareInstalls(progress installer.ProgressFunc) *UpdatePlan {
+	plan := &UpdatePlan{}
+	for _, step := range p.Steps {
+		if step.Release != nil {
+			plan.Results = append(plan.Results, PrepareInstall(step.App, step.Release, progress).Results...)
+		}
+	}
+	return plan
+}
+
+// Pruned returns the steps removing apps the manifest does not list.
+func (p *SyncPlan) Pruned() []SyncStep {
+	var pruned []SyncStep
+	for _, step := range p.Steps {
+		if step.Action == SyncUntrack || step.Action == SyncUninstall {
+			pruned = append(pruned, step)
+		}
+	}
+	return pruned
+}
+
+// Failed reports whether a listed app could not be planned.
+func (p *SyncPlan) Failed() bool {
+	for _, step := range p.Steps {
+		if step.Action == SyncFail {
+			return true
+		}
+	}
+	return false
+}
+
+// String renders one line per app with its planned action.
+func (p *SyncPlan) String() string {
+	var b strings.Builder
+	for _, step := range p.Steps {
+		reason := step.Reason
+		if step.New {
+			reason += " (newly tracked)"
+		}
+		fmt.Fprintf(&b, "%-10s %-24s %s\n", step.Action, step.App.Name, reason)
+	}
+	return b.String()
+}
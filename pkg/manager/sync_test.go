@@ -0,0 +1,24 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/manifest"
+)
+
+func TestPlanSync_PruneKeepsSelf(t *testing.T) {
+	cfg := &config.Config{Apps: []config.App{
+		{Name: "Autonomix CLI", RepoURL: SelfRepoURL, Version: "1.0.0"},
+		{Name: "tool", RepoURL: "https://github.com/owner/tool", Version: "2.0.0"},
+	}}
+
+	plan := PlanSync(cfg, &manifest.Manifest{}, SyncOptions{Prune: true, Uninstall: true})
+	pruned := plan.Pruned()
+	if len(pruned) != 1 || pruned[0].App.RepoURL != "https://github.com/owner/tool" {
+		t.Fatalf("Pruned() = %+v, want only tool", pruned)
+	}
+	if pruned[0].Action != SyncUninstall {
+		t.Errorf("tool action = %s, want %s", pruned[0].Action, SyncUninstall)
+	}
+}
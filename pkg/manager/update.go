@@ -0,0 +1,370 @@
+package manager
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/installer"
+	"github.com/tim/autonomix-cli/pkg/packages"
+	"github.com/tim/autonomix-cli/pkg/system"
+	"github.com/tim/autonomix-cli/pkg/verify"
+	"github.com/tim/autonomix-cli/pkg/version"
+)
+
+// UpdateOutcome is the final state of one app in an update run.
+type UpdateOutcome string
+
+const (
+	UpdatePending   UpdateOutcome = "pending"
+	UpdateSucceeded UpdateOutcome = "succeeded"
+	UpdateFailed    UpdateOutcome = "failed"
+	UpdateSkipped   UpdateOutcome = "skipped"
+)
+
+// UpdateResult records what happened to one app in an update run.
+type UpdateResult struct {
+	App     config.App
+	Outcome UpdateOutcome
+	From    string // installed version before the run
+	To      string // release tag that was installed
+	Version string // installed version after the run
+	Reason  string // why the app was skipped or failed
+
+	// Asset is the downloaded package and Verification how it was checked
+	Asset        string
+	Verification installer.Verification
+
+	installation system.Installation
+	exact        bool   // require exactly To, e.g. when installing a specific tag
+	path         string // downloaded package awaiting install
+}
+
+// UpdatePlan is an update run that has downloaded its packages and is ready
+// to hand them to the package manager in one batch.
+type UpdatePlan struct {
+	Results []UpdateResult
+}
+
+// PrepareUpdates checks every app for an update and downloads a compatible
+// package for each one that has one. Held apps are skipped unless force is set.
+// progress, if not nil, receives the progress of each download.
+func PrepareUpdates(apps []config.App, force bool, progress installer.ProgressFunc) *UpdatePlan {
+	plan := &UpdatePlan{}
+	for _, app := range apps {
+		plan.Results = append(plan.Results, prepareUpdate(app, force, progress))
+	}
+	return plan
+}
+
+func prepareUpdate(app config.App, force bool, progress installer.ProgressFunc) UpdateResult {
+	res := UpdateResult{App: app, From: app.Version}
+	skip := func(format string, args ...any) UpdateResult {
+		res.Outcome = UpdateSkipped
+		res.Reason = fmt.Sprintf(format, args...)
+		return res
+	}
+	fail := func(err error) UpdateResult {
+		res.Outcome = UpdateFailed
+		res.Reason = err.Error()
+		return res
+	}
+
+	if app.Pinned && !force {
+		return skip("held at %s", app.HoldVersion)
+	}
+	if app.Version == "" {
+		return skip("not installed")
+	}
+
+	rel, err := github.FetchChannelRelease(app.RepoURL, app.Channel, true)
+	if err != nil {
+		return fail(err)
+	}
+	res.To = rel.TagName
+	res.App.Latest = rel.TagName
+
+	switch status := version.Check(app.Version, rel.TagName); status {
+	case version.UpdateAvailable:
+	case version.Unknown:
+		return skip("cannot compare installed version %q with %s", app.Version, rel.TagName)
+	default:
+		return skip("%s", status)
+	}
+
+	return download(res, rel, progress)
+}
+
+// PrepareInstall downloads a compatible package of rel for app, regardless
+// of the installed version. The install only succeeds if it ends up at
+// exactly rel's version.
+func PrepareInstall(app config.App, rel *github.Release, progress installer.ProgressFunc) *UpdatePlan {
+	res := UpdateResult{App: app, From: app.Version, To: rel.TagName, exact: true}
+	return &UpdatePlan{Results: []UpdateResult{download(res, rel, progress)}}
+}
+
+// download fetches the asset of rel picked for the app and marks res pending.
+func download(res UpdateResult, rel *github.Release, progress installer.ProgressFunc) UpdateResult {
+	fail := func(err error) UpdateResult {
+		res.Outcome = UpdateFailed
+		res.Reason = err.Error()
+		return res
+	}
+
+	assets, err := installer.CompatibleAssetsFor(rel, AssetRules(res.App))
+	if err != nil {
+		return fail(err)
+	}
+	if len(assets) == 0 {
+		return fail(fmt.Errorf("no compatible assets found"))
+	}
+	return fetch(res, rel, pickAsset(res.App, assets), "", progress)
+}
+
+// fetch downloads asset of rel and marks res pending. A non-empty sum is the
+// SHA-256 the download must have, e.g. from a lockfile.
+func fetch(res UpdateResult, rel *github.Release, asset github.Asset, sum string, progress installer.ProgressFunc) UpdateResult {
+	fail := func(err error) UpdateResult {
+		res.Outcome = UpdateFailed
+		res.Reason = err.Error()
+		return res
+	}
+
+	trust, err := verify.ForApp(res.App)
+	if err != nil {
+		return fail(err)
+	}
+	res.Asset = asset.Name
+	var path string
+	var verification installer.Verification
+	if sum != "" {
+		path, verification, err = installer.DownloadLockedAsset(rel, &asset, sum, trust, progress)
+	} else {
+		path, verification, err = installer.DownloadVerifiedAsset(rel, &asset, trust, progress)
+	}
+	res.Verification = verification
+	if err != nil {
+		return fail(err)
+	}
+
+	// Record the exact name, e.g. a flatpak's application ID, so the
+	// install is found again without guessing
+	if name := installer.PackageName(path); name != "" {
+		res.App.PackageName = name
+		res.App.PackageType = string(packages.DetectType(path))
+	}
+
+	res.Outcome = UpdatePending
+	res.path = path
+	return res
+}
+
+// pickAsset prefers the asset remembered for the app, then an asset of the
+// type the app is installed as, so an AppImage is updated with an AppImage,
+// unless the app names a preferred type. Otherwise the first asset wins.
+func pickAsset(app config.App, assets []github.Asset) github.Asset {
+	if asset, ok := installer.FindRemembered(assets, app.AssetPattern); ok {
+		return asset
+	}
+	if app.PreferredType != "" {
+		return assets[0]
+	}
+	for _, asset := range assets {
+		if app.PackageType != "" && string(packages.DetectType(asset.Name)) == app.PackageType {
+			return asset
+		}
+	}
+	return assets[0]
+}
+
+// AssetRules returns the asset selection rules of app.
+func AssetRules(app config.App) installer.AssetRules {
+	return installer.AssetRules{
+		Preferred:  packages.Type(app.PreferredType),
+		Include:    app.AssetInclude,
+		Exclude:    app.AssetExclude,
+		Remembered: app.AssetPattern,
+	}
+}
+
+// HasAssetRules reports whether app has rules for picking its asset, in
+// which case the asset the user picks is remembered for later updates.
+func HasAssetRules(app config.App) bool {
+	return app.PreferredType != "" || len(app.AssetInclude) > 0 || len(app.AssetExclude) > 0
+}
+
+// PackageFor returns the package at path with the install options of app.
+func PackageFor(app config.App, path string) installer.Package {
+	return installer.Package{
+		Path:        path,
+		SnapClassic: app.SnapClassic,
+		BinaryGlob:  app.BinaryGlob,
+		BinDir:      app.BinDir,
+	}
+}
+
+// Discard removes the downloaded packages of a plan that will not be installed.
+func (p *UpdatePlan) Discard() {
+	for i := range p.Results {
+		res := &p.Results[i]
+		if res.Outcome != UpdatePending {
+			continue
+		}
+		os.Remove(res.path)
+		res.path = ""
+		res.Outcome = UpdateSkipped
+		res.Reason = "cancelled"
+	}
+}
+
+// Pending returns the number of apps waiting to be installed.
+func (p *UpdatePlan) Pending() int {
+	n := 0
+	for _, res := range p.Results {
+		if res.Outcome == UpdatePending {
+			n++
+		}
+	}
+	return n
+}
+
+// InstallUserPackages installs the pending packages that need no package
+// manager, such as AppImages. It must run before InstallCmd.
+func (p *UpdatePlan) InstallUserPackages() {
+	for i := range p.Results {
+		res := &p.Results[i]
+		if res.Outcome != UpdatePending || !installer.IsUserInstall(res.path) {
+			continue
+		}
+		_, err := installer.InstallUserPackage(PackageFor(res.App, res.path), res.App.Name, res.To)
+		os.Remove(res.path)
+		res.path = ""
+		if err != nil {
+			res.Outcome = UpdateFailed
+			res.Reason = fmt.Sprintf("install failed: %v", err)
+		}
+	}
+}
+
+// InstallCmd returns one command installing every pending package through the
+// package manager, or nil if there is none left after InstallUserPackages.
+// Like installer.GetInstallCmd it leaves Stdin/Stdout/Stderr unset.
+func (p *UpdatePlan) InstallCmd() (*exec.Cmd, error) {
+	var pkgs []installer.Package
+	for _, res := range p.Results {
+		if res.Outcome == UpdatePending && res.path != "" {
+			pkgs = append(pkgs, PackageFor(res.App, res.path))
+		}
+	}
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+	return installer.GetPackagesInstallCmd(pkgs)
+}
+
+// Finish removes the downloaded packages and re-detects the installed
+// version of every pending app to decide whether its update succeeded.
+// installErr is the error returned by the install command, if any.
+func (p *UpdatePlan) Finish(installErr error) {
+	if p.Pending() > 0 {
+		// Wait for package manager database to update
+		time.Sleep(1 * time.Second)
+	}
+	for i := range p.Results {
+		res := &p.Results[i]
+		if res.Outcome != UpdatePending {
+			continue
+		}
+		if res.path != "" {
+			os.Remove(res.path)
+			res.path = ""
+		}
+
+		// A failed batch may still have installed some of the packages
+		inst, _ := DetectInstalled(res.App)
+		res.installation = inst
+		ver := inst.Version
+		res.Version = ver
+		switch status := version.Check(ver, res.To); {
+		case status == version.UpToDate || (status == version.Ahead && !res.exact):
+			res.Outcome = UpdateSucceeded
+		case installErr != nil:
+			res.Outcome = UpdateFailed
+			res.Reason = fmt.Sprintf("install failed: %v", installErr)
+		default:
+			res.Outcome = UpdateFailed
+			res.Reason = fmt.Sprintf("installed version is still %s", ver)
+		}
+	}
+}
+
+// Failed reports whether any app failed to update.
+func (p *UpdatePlan) Failed() bool {
+	for _, res := range p.Results {
+		if res.Outcome == UpdateFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply copies the latest tags, new installed versions and installed assets
+// into cfg, matching apps by repository. Held apps stay held at the version
+// that was installed. Save them with UpdateConfig.
+func (p *UpdatePlan) Apply(cfg *config.Config) {
+	for _, res := range p.Results {
+		for i := range cfg.Apps {
+			if cfg.Apps[i].RepoURL != res.App.RepoURL {
+				continue
+			}
+			if res.App.Latest != "" {
+				cfg.Apps[i].Latest = res.App.Latest
+			}
+			if res.Version != "" {
+				RecordInstallation(&cfg.Apps[i], res.installation)
+			}
+			switch res.Outcome {
+			case UpdateSucceeded:
+				RecordInstalledAsset(&cfg.Apps[i], res.To, res.Asset, res.Verification.Checksum.Actual)
+				// A forced or explicit install moves the hold along
+				if cfg.Apps[i].Pinned {
+					cfg.Apps[i].HoldVersion = res.Version
+				}
+				cfg.Apps[i].LastError = ""
+			case UpdateFailed:
+				cfg.Apps[i].LastError = res.Reason
+			}
+			break
+		}
+	}
+}
+
+// Verification renders one line per pending package with its checksum and
+// signature status, to be shown before the install runs.
+func (p *UpdatePlan) Verification() string {
+	var b strings.Builder
+	for _, res := range p.Results {
+		if res.Outcome == UpdatePending {
+			fmt.Fprintf(&b, "%-24s %-40s %s\n", res.App.Name, res.Asset, res.Verification)
+		}
+	}
+	return b.String()
+}
+
+// Summary renders one line per app with its outcome.
+func (p *UpdatePlan) Summary() string {
+	var b strings.Builder
+	for _, res := range p.Results {
+		detail := res.Reason
+		if res.Outcome == UpdateSucceeded {
+			detail = fmt.Sprintf("%s -> %s, checksum %s, signature %s", res.From, res.Version,
+				res.Verification.Checksum.Status, res.Verification.Signature.Status)
+		}
+		fmt.Fprintf(&b, "%-10s %-24s %s\n", res.Outcome, res.App.Name, detail)
+	}
+	return b.String()
+}
@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/installer"
+	"github.com/tim/autonomix-cli/pkg/system"
+)
+
+func TestUpdatePlanApply_RecordsInstalledAsset(t *testing.T) {
+	app := config.App{Name: "app", RepoURL: "https://github.com/owner/app", Version: "1.2.3-1"}
+	cfg := &config.Config{Apps: []config.App{app}}
+	plan := &UpdatePlan{Results: []UpdateResult{{
+		App:          app,
+		Outcome:      UpdateSucceeded,
+		To:           "v1.3.0",
+		Version:      "1.3.0-1",
+		Asset:        "app_1.3.0_amd64.deb",
+		Verification: installer.Verification{Checksum: installer.ChecksumResult{Actual: "abc123"}},
+		installation: system.Installation{Version: "1.3.0-1"},
+	}}}
+
+	plan.Apply(cfg)
+	got := cfg.Apps[0]
+	if got.Version != "1.3.0-1" || got.InstalledTag != "v1.3.0" || got.InstalledAsset != "app_1.3.0_amd64.deb" || got.InstalledSHA256 != "abc123" {
+		t.Errorf("Apply() = %+v, want the v1.3.0 deb recorded", got)
+	}
+}
+
+func TestUpdatePlanApply_MovesHold(t *testing.T) {
+	app := config.App{Name: "app", RepoURL: "https://github.com/owner/app", Version: "1.2.0", Pinned: true, HoldVersion: "1.2.0"}
+	cfg := &config.Config{Apps: []config.App{app}}
+	failed := &UpdatePlan{Results: []UpdateResult{{App: app, Outcome: UpdateFailed, To: "v1.3.0", Reason: "install failed"}}}
+	failed.Apply(cfg)
+	if cfg.Apps[0].HoldVersion != "1.2.0" {
+		t.Errorf("HoldVersion after a failed install = %s, want 1.2.0", cfg.Apps[0].HoldVersion)
+	}
+
+	forced := &UpdatePlan{Results: []UpdateResult{{
+		App:          app,
+		Outcome:      UpdateSucceeded,
+		To:           "v1.3.0",
+		Version:      "1.3.0",
+		installation: system.Installation{Version: "1.3.0"},
+	}}}
+	forced.Apply(cfg)
+	if got := cfg.Apps[0]; !got.Pinned || got.HoldVersion != "1.3.0" {
+		t.Errorf("after a forced install = pinned %v at %s, want held at 1.3.0", got.Pinned, got.HoldVersion)
+	}
+}
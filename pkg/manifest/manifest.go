@@ -0,0 +1,141 @@
+// Package manifest reads the YAML files that declare which apps a machine
+// tracks, so "autonomix-cli sync" can converge on them:
+//
+//	apps:
+//	  - repo: junegunn/fzf
+//	    version: ">=0.50, <1"
+//	  - repo: https://github.com/BurntSushi/ripgrep
+//	    asset_include: ["*-musl.tar.gz"]
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+	"github.com/tim/autonomix-cli/pkg/version"
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the list of apps a machine should track.
+type Manifest struct {
+	Apps []App `yaml:"apps"`
+}
+
+// App declares one app. Its settings mirror those of config.App and replace
+// them on every sync; settings left out are cleared.
+type App struct {
+	// Repo is the GitHub repository, as a URL or "owner/repo".
+	Repo string `yaml:"repo"`
+	// Name is shown in lists; defaults to the repository name.
+	Name string `yaml:"name,omitempty"`
+	// Version is a constraint the installed version must satisfy, see
+	// version.ParseConstraint. Without one the newest release is installed.
+	Version string `yaml:"version,omitempty"`
+
+	Channel       string   `yaml:"channel,omitempty"`
+	PreferredType string   `yaml:"preferred_type,omitempty"`
+	AssetInclude  []string `yaml:"asset_include,omitempty"`
+	AssetExclude  []string `yaml:"asset_exclude,omitempty"`
+	BinaryGlob    string   `yaml:"binary_glob,omitempty"`
+	BinDir        string   `yaml:"bin_dir,omitempty"`
+	SnapClassic   bool     `yaml:"snap_classic,omitempty"`
+
+	constraint version.Constraint
+}
+
+// Load reads and validates the manifest at path.
+func Load(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Parse decodes and validates a manifest. Unknown keys are rejected so a
+// typo does not silently drop a setting. Repositories are normalized to
+// their bare URL.
+func Parse(data []byte) (*Manifest, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var m Manifest
+	if err := dec.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for i := range m.Apps {
+		app := &m.Apps[i]
+		if err := app.validate(); err != nil {
+			return nil, fmt.Errorf("app %d: %w", i+1, err)
+		}
+		key := strings.ToLower(app.Repo)
+		if seen[key] {
+			return nil, fmt.Errorf("app %d: %s is listed twice", i+1, app.Repo)
+		}
+		seen[key] = true
+	}
+	return &m, nil
+}
+
+func (a *App) validate() error {
+	repo := strings.TrimSpace(a.Repo)
+	if repo == "" {
+		return fmt.Errorf("repo is required")
+	}
+	if !strings.Contains(repo, "github.com") && strings.Count(strings.Trim(repo, "/"), "/") == 1 {
+		repo = "https://github.com/" + strings.Trim(repo, "/")
+	}
+	a.Repo = github.CleanRepoURL(repo)
+	if path, err := github.RepoPath(a.Repo); err != nil || strings.Count(path, "/") != 1 {
+		return fmt.Errorf("%q is not a GitHub repository", a.Repo)
+	}
+	if err := github.ValidateChannel(a.Channel); err != nil {
+		return fmt.Errorf("%s: %w", a.Repo, err)
+	}
+	c, err := version.ParseConstraint(a.Version)
+	if err != nil {
+		return fmt.Errorf("%s: %w", a.Repo, err)
+	}
+	a.constraint = c
+	return nil
+}
+
+// Constraint returns the parsed version constraint of a.
+func (a App) Constraint() version.Constraint {
+	return a.constraint
+}
+
+// DefaultName is the name of a newly tracked app: Name, or the repository
+// name.
+func (a App) DefaultName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return a.Repo[strings.LastIndex(a.Repo, "/")+1:]
+}
+
+// Apply copies the settings of a onto app.
+func (a App) Apply(app *config.App) {
+	app.RepoURL = a.Repo
+	if a.Name != "" {
+		app.Name = a.Name
+	}
+	app.Channel = a.Channel
+	app.PreferredType = a.PreferredType
+	app.AssetInclude = a.AssetInclude
+	app.AssetExclude = a.AssetExclude
+	app.BinaryGlob = a.BinaryGlob
+	app.BinDir = a.BinDir
+	app.SnapClassic = a.SnapClassic
+}
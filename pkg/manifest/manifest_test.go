@@ -0,0 +1,73 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tim/autonomix-cli/config"
+)
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(`
+apps:
+  - repo: junegunn/fzf
+    version: ">=0.50, <1"
+  - repo: https://github.com/BurntSushi/ripgrep/releases/
+    name: ripgrep
+    channel: prerelease
+    asset_include: ["*-musl.tar.gz"]
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(m.Apps) != 2 {
+		t.Fatalf("Parse() = %d apps, want 2", len(m.Apps))
+	}
+	if got := m.Apps[0].Repo; got != "https://github.com/junegunn/fzf" {
+		t.Errorf("shorthand repo = %q", got)
+	}
+	if got := m.Apps[1].Repo; got != "https://github.com/BurntSushi/ripgrep" {
+		t.Errorf("repo URL = %q", got)
+	}
+	if c := m.Apps[0].Constraint(); !c.Check("0.55.0") || c.Check("1.0.0") {
+		t.Errorf("constraint %s not parsed", c)
+	}
+	if !m.Apps[1].Constraint().IsAny() {
+		t.Error("app without a version should allow any")
+	}
+	if got := m.Apps[0].DefaultName(); got != "fzf" {
+		t.Errorf("DefaultName() = %q, want fzf", got)
+	}
+
+	app := config.App{Name: "rg", Channel: "stable", BinaryGlob: "*/rg", AssetPattern: "rg-*"}
+	m.Apps[1].Apply(&app)
+	if app.Name != "ripgrep" || app.Channel != "prerelease" || app.BinaryGlob != "" || app.AssetPattern != "rg-*" {
+		t.Errorf("Apply() = %+v", app)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing repo":   "apps:\n  - version: 1.0\n",
+		"not github":     "apps:\n  - repo: https://gitlab.com/owner/repo\n",
+		"duplicate":      "apps:\n  - repo: owner/repo\n  - repo: https://github.com/Owner/Repo\n",
+		"bad constraint": "apps:\n  - repo: owner/repo\n    version: latest\n",
+		"bad channel":    "apps:\n  - repo: owner/repo\n    channel: \"(\"\n",
+		"unknown key":    "apps:\n  - repo: owner/repo\n    verison: 1.0\n",
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: Parse() succeeded", name)
+		}
+	}
+}
+
+func TestParse_Empty(t *testing.T) {
+	m, err := Parse(nil)
+	if err != nil || len(m.Apps) != 0 {
+		t.Errorf("Parse(nil) = %+v, %v", m, err)
+	}
+	if _, err := Parse([]byte("apps: {}")); err == nil || !strings.Contains(err.Error(), "cannot unmarshal") {
+		t.Errorf("Parse() of a map = %v", err)
+	}
+}
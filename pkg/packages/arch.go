@@ -0,0 +1,178 @@
+package packages
+
+import (
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+)
+
+// Universal is returned by AssetArchs for assets that run on any
+// architecture, e.g. "_all.deb" or ".noarch.rpm".
+const Universal = "universal"
+
+// archAliases maps the architecture names found in release filenames to
+// GOARCH names. 32-bit ARM keeps its version: "arm" (any), "armv5",
+// "armv6" or "armv7".
+var archAliases = map[string]string{
+	"amd64": "amd64", "x64": "amd64", "x8664": "amd64", "linux64": "amd64",
+	"386": "386", "i386": "386", "i486": "386", "i586": "386", "i686": "386", "x86": "386", "ia32": "386", "linux32": "386",
+	"arm64": "arm64", "aarch64": "arm64", "armv8": "arm64",
+	"arm": "arm", "arm32": "arm",
+	"armv7": "armv7", "armv7l": "armv7", "armv7a": "armv7", "armv7hl": "armv7", "armhf": "armv7", "armhfp": "armv7",
+	"armv6": "armv6", "armv6l": "armv6", "armv6hf": "armv6",
+	"armv5": "armv5", "armv5l": "armv5", "armv5te": "armv5", "armel": "armv5",
+	"ppc64le": "ppc64le", "ppc64el": "ppc64le", "powerpc64le": "ppc64le",
+	"ppc64": "ppc64", "powerpc64": "ppc64",
+	"s390x": "s390x", "riscv64": "riscv64", "riscv64gc": "riscv64",
+	"mips": "mips", "mipsle": "mipsle", "mipsel": "mipsle",
+	"mips64": "mips64", "mips64le": "mips64le", "mips64el": "mips64le",
+	"loong64": "loong64", "loongarch64": "loong64",
+	"all": Universal, "noarch": Universal, "any": Universal,
+}
+
+// bitHints are the vaguer "64-bit" style names, only used when a filename
+// names no architecture otherwise.
+var bitHints = map[string]string{
+	"64bit": "amd64", "32bit": "386",
+}
+
+// Arch is the architecture assets are matched against.
+type Arch struct {
+	GOARCH string
+	// GOARM is the ARM version for GOARCH "arm".
+	GOARM int
+}
+
+// HostArch returns the architecture autonomix runs on. For 32-bit ARM the
+// version is the GOARM it was built with, defaulting to 7.
+func HostArch() Arch {
+	host := Arch{GOARCH: runtime.GOARCH}
+	if host.GOARCH != "arm" {
+		return host
+	}
+	host.GOARM = 7
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			if s.Key == "GOARM" && s.Value != "" {
+				if v, err := strconv.Atoi(s.Value[:1]); err == nil {
+					host.GOARM = v
+				}
+			}
+		}
+	}
+	return host
+}
+
+func (a Arch) String() string {
+	if a.GOARCH == "arm" && a.GOARM > 0 {
+		return "armv" + strconv.Itoa(a.GOARM)
+	}
+	return a.GOARCH
+}
+
+// Runs reports whether a binary for the architecture named arch, as returned
+// by AssetArchs, runs on a.
+func (a Arch) Runs(arch string) bool {
+	if arch == Universal {
+		return true
+	}
+	if a.GOARCH != "arm" {
+		return arch == a.GOARCH
+	}
+	if arch == "arm" {
+		return true
+	}
+	v, ok := strings.CutPrefix(arch, "armv")
+	if !ok {
+		return false
+	}
+	n, err := strconv.Atoi(v)
+	return err == nil && n <= a.GOARM
+}
+
+// AssetArchs returns the architectures a filename names, as GOARCH names
+// (see archAliases) or Universal. The name is split into words, so "arm64"
+// inside another word is not mistaken for an architecture.
+func AssetArchs(filename string) []string {
+	words := strings.FieldsFunc(strings.ToLower(filename), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	var archs []string
+	add := func(arch string) {
+		for _, a := range archs {
+			if a == arch {
+				return
+			}
+		}
+		archs = append(archs, arch)
+	}
+	var hint string
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		// Separators split "x86_64", "x86-64" and "64-bit" into two words
+		if i+1 < len(words) {
+			switch pair := word + words[i+1]; {
+			case pair == "x8664":
+				add("amd64")
+				i++
+				continue
+			case bitHints[pair] != "":
+				hint = bitHints[pair]
+				i++
+				continue
+			}
+		}
+		if arch, ok := archAliases[word]; ok {
+			add(arch)
+		} else if h, ok := bitHints[word]; ok {
+			hint = h
+		}
+	}
+	if len(archs) == 0 && hint != "" {
+		archs = append(archs, hint)
+	}
+	return archs
+}
+
+// ArchMatch is how an asset's architecture relates to the host's.
+type ArchMatch int
+
+const (
+	// ArchUnspecified assets name no architecture.
+	ArchUnspecified ArchMatch = iota
+	// ArchNative assets are built for the host.
+	ArchNative
+	// ArchUniversal assets run on any architecture.
+	ArchUniversal
+	// ArchForeign assets only name other architectures.
+	ArchForeign
+)
+
+// MatchArch classifies the asset filename for host.
+func MatchArch(filename string, host Arch) ArchMatch {
+	archs := AssetArchs(filename)
+	if len(archs) == 0 {
+		return ArchUnspecified
+	}
+	universal := false
+	for _, arch := range archs {
+		if arch == Universal {
+			universal = true
+		} else if host.Runs(arch) {
+			return ArchNative
+		}
+	}
+	// "all" next to a foreign architecture is a word, not a package arch
+	if universal && len(archs) == 1 {
+		return ArchUniversal
+	}
+	return ArchForeign
+}
+
+// Compatible reports whether an asset of this match can be installed on the
+// host.
+func (m ArchMatch) Compatible() bool {
+	return m == ArchNative || m == ArchUniversal
+}
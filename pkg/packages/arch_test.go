@@ -0,0 +1,94 @@
+package packages
+
+import (
+	"strings"
+	"testing"
+)
+
+// archCorpus is built from the names of real release assets.
+var archCorpus = []struct {
+	name string
+	want string // comma-separated AssetArchs, empty for none
+}{
+	// Rust target triples
+	{"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz", "amd64"},
+	{"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz", "arm64"},
+	{"ripgrep-14.1.0-armv7-unknown-linux-gnueabihf.tar.gz", "armv7"},
+	{"ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz", "386"},
+	{"fd-v9.0.0-arm-unknown-linux-gnueabihf.tar.gz", "arm"},
+	{"zellij-riscv64gc-unknown-linux-gnu.tar.gz", "riscv64"},
+	{"deno-x86_64-unknown-linux-gnu.zip", "amd64"},
+	// goreleaser
+	{"gh_2.40.0_linux_amd64.deb", "amd64"},
+	{"gh_2.40.0_linux_arm64.rpm", "arm64"},
+	{"gh_2.40.0_linux_armv6.tar.gz", "armv6"},
+	{"gh_2.40.0_linux_386.tar.gz", "386"},
+	{"lazygit_0.40.2_Linux_x86_64.tar.gz", "amd64"},
+	{"lazygit_0.40.2_Linux_32-bit.tar.gz", "386"},
+	{"hugo_extended_0.121.1_linux-arm64.tar.gz", "arm64"},
+	{"k9s_Linux_ppc64le.tar.gz", "ppc64le"},
+	{"k9s_Linux_s390x.tar.gz", "s390x"},
+	{"syncthing-linux-mips64le-v1.27.1.tar.gz", "mips64le"},
+	{"syncthing-linux-loong64-v1.27.1.tar.gz", "loong64"},
+	// Distribution packages
+	{"ripgrep_14.1.0-1_amd64.deb", "amd64"},
+	{"code_1.85.1-1702462158_armhf.deb", "armv7"},
+	{"code-1.85.1-1702462241.el7.aarch64.rpm", "arm64"},
+	{"hello-2.12-1.fc39.ppc64le.rpm", "ppc64le"},
+	{"app-1.0.0-1.noarch.rpm", "universal"},
+	{"app_1.0.0_all.deb", "universal"},
+	{"yay-12.2.0-1-any.pkg.tar.zst", "universal"},
+	// AppImages and others
+	{"helix-23.10-x86_64.AppImage", "amd64"},
+	{"Obsidian-1.4.16-arm64.AppImage", "arm64"},
+	{"node-v20.10.0-linux-x64.tar.xz", "amd64"},
+	{"node-v20.10.0-linux-armv7l.tar.xz", "armv7"},
+	{"nvim-linux64.tar.gz", "amd64"},
+	// Words merely containing an architecture name
+	{"swarm64-x86_64.tar.gz", "amd64"},
+	{"install-tool-linux.tar.gz", ""},
+	{"myapp.flatpak", ""},
+}
+
+func TestAssetArchs(t *testing.T) {
+	for _, tt := range archCorpus {
+		got := strings.Join(AssetArchs(tt.name), ",")
+		if got != tt.want {
+			t.Errorf("AssetArchs(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMatchArch(t *testing.T) {
+	amd64 := Arch{GOARCH: "amd64"}
+	arm64 := Arch{GOARCH: "arm64"}
+	armv6 := Arch{GOARCH: "arm", GOARM: 6}
+	armv7 := Arch{GOARCH: "arm", GOARM: 7}
+
+	tests := []struct {
+		name string
+		host Arch
+		want ArchMatch
+	}{
+		{"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz", amd64, ArchNative},
+		{"ripgrep-14.1.0-x86_64-unknown-linux-musl.tar.gz", arm64, ArchForeign},
+		{"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz", arm64, ArchNative},
+		{"ripgrep-14.1.0-aarch64-unknown-linux-gnu.tar.gz", amd64, ArchForeign},
+		{"ripgrep-14.1.0-i686-unknown-linux-gnu.tar.gz", amd64, ArchForeign},
+		{"gh_2.40.0_linux_armv6.tar.gz", armv7, ArchNative},
+		{"gh_2.40.0_linux_armv6.tar.gz", armv6, ArchNative},
+		{"code_1.85.1-1702462158_armhf.deb", armv6, ArchForeign},
+		{"code_1.85.1-1702462158_armhf.deb", arm64, ArchForeign},
+		{"fd-v9.0.0-arm-unknown-linux-gnueabihf.tar.gz", armv7, ArchNative},
+		{"k9s_Linux_ppc64le.tar.gz", amd64, ArchForeign},
+		{"app_1.0.0_all.deb", arm64, ArchUniversal},
+		{"yay-12.2.0-1-any.pkg.tar.zst", amd64, ArchUniversal},
+		{"tool-all-arm64.tar.gz", amd64, ArchForeign},
+		{"install-tool-linux.tar.gz", amd64, ArchUnspecified},
+	}
+	for _, tt := range tests {
+		if got := MatchArch(tt.name, tt.host); got != tt.want {
+			t.Errorf("MatchArch(%q, %s) = %d, want %d", tt.name, tt.host, got, tt.want)
+		}
+	}
+}
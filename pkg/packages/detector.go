@@ -13,9 +13,17 @@ const (
 	Snap    Type = "snap"
 	Pacman  Type = "pacman"
 	AppImage Type = "appimage"
+	Archive Type = "archive"   // tarball or zip holding a binary
+	RawBinary Type = "binary"  // a bare executable
 	Unknown Type = "unknown"
 )
 
+// archiveSuffixes are the archive formats the installer can extract.
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".tar.bz2", ".tbz2", ".tar.xz", ".txz", ".tar", ".zip"}
+
+// notBinarySuffixes are release files that are never executables.
+var notBinarySuffixes = []string{".txt", ".md", ".json", ".yaml", ".yml", ".sig", ".asc", ".pem", ".minisig", ".bundle", ".sbom", ".sha256", ".sha512", ".sha256sum", ".exe", ".dmg", ".pkg", ".msi"}
+
 func DetectType(filename string) Type {
 	lower := strings.ToLower(filename)
 	if strings.HasSuffix(lower, ".deb") {
@@ -36,9 +44,47 @@ func DetectType(filename string) Type {
 	if strings.HasSuffix(lower, ".appimage") {
 		return AppImage // Bonus, usually useful
 	}
+	// Archives and bare binaries only count when built for Linux,
+	// e.g. "tool_linux_amd64.tar.gz" or "tool-x86_64-unknown-linux-musl"
+	if !strings.Contains(lower, "linux") {
+		return Unknown
+	}
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return Archive
+		}
+	}
+	for _, suffix := range notBinarySuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return Unknown
+		}
+	}
+	if isBinaryName(lower) {
+		return RawBinary
+	}
 	return Unknown
 }
 
+// isBinaryName reports whether a file name has no extension, as executables
+// usually do. Dots within a version ("tool-1.2.3-linux-amd64"), a trailing
+// version number or ".bin" do not count as an extension.
+func isBinaryName(lower string) bool {
+	dot := strings.LastIndex(lower, ".")
+	if dot < 0 {
+		return true
+	}
+	ext := lower[dot+1:]
+	if ext == "bin" || strings.ContainsAny(ext, "-_") {
+		return true
+	}
+	for _, r := range ext {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return ext != ""
+}
+
 func DisplayName(t Type) string {
 	switch t {
 	case Deb:
@@ -53,6 +99,10 @@ func DisplayName(t Type) string {
 		return "Arch Package"
 	case AppImage:
 		return "AppImage"
+	case Archive:
+		return "Archive (.tar.gz, .zip)"
+	case RawBinary:
+		return "Binary"
 	default:
 		return "Unknown"
 	}
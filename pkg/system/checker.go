@@ -8,9 +8,23 @@ import (
 	"github.com/tim/autonomix-cli/pkg/packages"
 )
 
+// Installation describes how an application is installed on the system.
+type Installation struct {
+	Name    string        // package name, flatpak application ID or binary name
+	Version string
+	Type    packages.Type // packages.Unknown for a bare binary found in PATH
+}
+
 // CheckInstalled checks if an application is installed via various package managers.
 // It returns the version string, the package type, true if found.
 func CheckInstalled(appName string) (string, packages.Type, bool) {
+	inst, ok := FindInstalled(appName)
+	return inst.Version, inst.Type, ok
+}
+
+// FindInstalled is CheckInstalled returning the matched package name as well,
+// which is what a package manager needs to remove it.
+func FindInstalled(appName string) (Installation, bool) {
 	// Generate candidate names to check
 	// e.g. "My App" -> ["My App", "my app", "my-app"]
 	candidates := []string{appName}
@@ -55,38 +69,71 @@ func CheckInstalled(appName string) (string, packages.Type, bool) {
 		
 		// Try each package manager with this name
 		
+		// Check installs made by autonomix itself, e.g. AppImages
+		if rec, ok := LoadLocalInstall(name); ok {
+			return Installation{Name: rec.Name, Version: rec.Version, Type: rec.Type}, true
+		}
+		
 		// Check Snap
 		if ver, ok := checkSnap(name); ok {
-			return ver, packages.Snap, true
+			return Installation{Name: name, Version: ver, Type: packages.Snap}, true
 		}
 		
 		// Check Flatpak
-		if ver, ok := checkFlatpak(name); ok {
-			return ver, packages.Flatpak, true
+		if id, ver, ok := checkFlatpak(name); ok {
+			return Installation{Name: id, Version: ver, Type: packages.Flatpak}, true
 		}
 		
 		// Check Dpkg (Debian/Ubuntu)
 		if ver, ok := checkDpkg(name); ok {
-			return ver, packages.Deb, true
+			return Installation{Name: name, Version: ver, Type: packages.Deb}, true
 		}
 		
 		// Check Pacman (Arch)
 		if ver, ok := checkPacman(name); ok {
-			return ver, packages.Pacman, true
+			return Installation{Name: name, Version: ver, Type: packages.Pacman}, true
 		}
 		
 		// Check RPM
 		if ver, ok := checkRpm(name); ok {
-			return ver, packages.Rpm, true
+			return Installation{Name: name, Version: ver, Type: packages.Rpm}, true
 		}
 
 		// Check Binary in Path (Fallback)
 		if ver, ok := checkBinary(name); ok {
-			return ver, packages.Unknown, true
+			return Installation{Name: name, Version: ver, Type: packages.Unknown}, true
 		}
 	}
 
-	return "", packages.Unknown, false
+	return Installation{Type: packages.Unknown}, false
+}
+
+// FindPackage looks up a package by its exact name with the package manager
+// of pkgType, e.g. a flatpak by its application ID. Unlike FindInstalled it
+// does not guess alternative names.
+func FindPackage(pkgType packages.Type, name string) (Installation, bool) {
+	var ver string
+	var ok bool
+	switch pkgType {
+	case packages.Snap:
+		ver, ok = checkSnap(name)
+	case packages.Flatpak:
+		ver, ok = checkFlatpakID(name)
+	case packages.Deb:
+		ver, ok = checkDpkg(name)
+	case packages.Pacman:
+		ver, ok = checkPacman(name)
+	case packages.Rpm:
+		ver, ok = checkRpm(name)
+	case packages.AppImage, packages.Archive, packages.RawBinary:
+		var rec LocalInstall
+		rec, ok = LoadLocalInstall(name)
+		ver = rec.Version
+	}
+	if !ok {
+		return Installation{Type: packages.Unknown}, false
+	}
+	return Installation{Name: name, Version: ver, Type: pkgType}, true
 }
 
 func checkBinary(name string) (string, bool) {
@@ -189,12 +236,12 @@ func checkSnap(name string) (string, bool) {
 	return "", false
 }
 
-func checkFlatpak(name string) (string, bool) {
+func checkFlatpak(name string) (string, string, bool) {
 	// flatpak list --app --columns=application,version
 	cmd := exec.Command("flatpak", "list", "--app", "--columns=application,name,version")
 	out, err := cmd.Output()
 	if err != nil {
-		return "", false
+		return "", "", false
 	}
 	
 	lowerName := strings.ToLower(name)
@@ -207,10 +254,31 @@ func checkFlatpak(name string) (string, bool) {
 			
 			// Heuristic: if ID ends with name or name matches
 			if appName == lowerName || strings.HasSuffix(appID, "." + lowerName) {
-				return fields[2], true
+				return fields[0], fields[2], true
 			}
 		}
 	}
+	return "", "", false
+}
+
+// checkFlatpakID returns the version of the flatpak with application ID id.
+func checkFlatpakID(id string) (string, bool) {
+	cmd := exec.Command("flatpak", "list", "--app", "--columns=application,version")
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] != id {
+			continue
+		}
+		if len(fields) >= 2 {
+			return fields[1], true
+		}
+		// Installed, but the app does not declare a version
+		return "", true
+	}
 	return "", false
 }
 
@@ -0,0 +1,147 @@
+package system
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/packages"
+)
+
+// LocalInstall records an app that autonomix installed into the user's home
+// directory itself, rather than through a package manager.
+type LocalInstall struct {
+	Name    string        `json:"name"`
+	Version string        `json:"version"`
+	Type    packages.Type `json:"type"`
+	// Path is the installed file, Files everything created for it
+	// (symlinks, desktop entries, icons), including Path.
+	Path  string   `json:"path"`
+	Files []string `json:"files"`
+}
+
+// DataHome returns $XDG_DATA_HOME, defaulting to ~/.local/share. With
+// AUTONOMIX_HOME set it is the data/ directory below it.
+func DataHome() (string, error) {
+	if root := os.Getenv(config.HomeEnv); root != "" {
+		return filepath.Join(root, "data"), nil
+	}
+	if dir := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share"), nil
+}
+
+// binDir overrides BinDir, see SetBinDir.
+var binDir string
+
+// SetBinDir registers the bin directory from the config. "~/" is expanded;
+// an empty dir restores the default.
+func SetBinDir(dir string) {
+	binDir = dir
+}
+
+// BinDir returns the directory user-level installs put their executables
+// in: the one set with SetBinDir, or ~/.local/bin (bin/ below
+// AUTONOMIX_HOME if set).
+func BinDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	if binDir != "" {
+		return ExpandHome(binDir, home), nil
+	}
+	if root := os.Getenv(config.HomeEnv); root != "" {
+		return filepath.Join(root, "bin"), nil
+	}
+	return filepath.Join(home, ".local", "bin"), nil
+}
+
+// ExpandHome replaces a leading "~/" in path with home.
+func ExpandHome(path, home string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
+
+// LocalDir returns the directory holding user-level installs. Their records
+// live in the state directory, see LocalRecordPath.
+func LocalDir() (string, error) {
+	data, err := DataHome()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(data, "autonomix"), nil
+}
+
+// LocalName turns an app name into the name used for its files,
+// e.g. "My App" -> "my-app".
+func LocalName(appName string) string {
+	lower := strings.ToLower(strings.TrimSpace(appName))
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '.', r == '_', r == '-':
+			return r
+		}
+		return '-'
+	}, lower)
+}
+
+// LocalRecordPath returns where the record of the user-level install name is
+// kept: below the state directory, as it is bookkeeping rather than app data.
+func LocalRecordPath(name string) (string, error) {
+	dir, err := config.GetStateDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "installed", LocalName(name)+".json"), nil
+}
+
+// SaveLocalInstall writes the record of a user-level install.
+func SaveLocalInstall(rec LocalInstall) error {
+	path, err := LocalRecordPath(rec.Name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// LoadLocalInstall returns the record of the user-level install name, if its
+// installed file still exists.
+func LoadLocalInstall(name string) (LocalInstall, bool) {
+	path, err := LocalRecordPath(name)
+	if err != nil {
+		return LocalInstall{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return LocalInstall{}, false
+	}
+	var rec LocalInstall
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return LocalInstall{}, false
+	}
+	if _, err := os.Stat(rec.Path); err != nil {
+		return LocalInstall{}, false
+	}
+	return rec, true
+}
@@ -0,0 +1,104 @@
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// Only key-pair cosign signatures are checked: the signature must match the
+// pinned public key. Keyless certificates and transparency log entries in a
+// bundle are ignored.
+
+// verifyCosign checks a base64 signature written by "cosign sign-blob".
+func verifyCosign(pemKey, path string, sigFile []byte) error {
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigFile)))
+	if err != nil {
+		return unusable("malformed cosign signature")
+	}
+	return verifyBlob(pemKey, path, sig)
+}
+
+// cosignBundle covers both the legacy "cosign sign-blob --bundle" format and
+// the Sigstore bundle format.
+type cosignBundle struct {
+	Base64Signature  string `json:"base64Signature"`
+	MessageSignature *struct {
+		Signature string `json:"signature"`
+	} `json:"messageSignature"`
+}
+
+// verifyCosignBundle checks the signature held in a cosign bundle.
+func verifyCosignBundle(pemKey, path string, data []byte) error {
+	var bundle cosignBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return unusable("malformed cosign bundle: %v", err)
+	}
+	encoded := bundle.Base64Signature
+	if bundle.MessageSignature != nil {
+		encoded = bundle.MessageSignature.Signature
+	}
+	if encoded == "" {
+		return unusable("cosign bundle holds no message signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return unusable("malformed cosign bundle signature")
+	}
+	return verifyBlob(pemKey, path, sig)
+}
+
+func verifyBlob(pemKey, path string, sig []byte) error {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return unusable("invalid cosign public key: no PEM block")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return unusable("invalid cosign public key: %v", err)
+	}
+
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		digest, err := sha256File(path)
+		if err != nil {
+			return err
+		}
+		if !ecdsa.VerifyASN1(key, digest, sig) {
+			return errors.New("cosign signature does not match")
+		}
+	case ed25519.PublicKey:
+		message, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !ed25519.Verify(key, message, sig) {
+			return errors.New("cosign signature does not match")
+		}
+	default:
+		return unusable("unsupported cosign key type %T", pub)
+	}
+	return nil
+}
+
+func sha256File(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
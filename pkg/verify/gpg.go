@@ -0,0 +1,59 @@
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// verifyGPG checks an OpenPGP detached signature with the gpg binary. The
+// pinned key is imported into a throwaway keyring, so the user's own keyring
+// is neither used nor modified.
+func verifyGPG(armoredKey, path string, sig []byte) error {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		return unusable("gpg is not installed")
+	}
+
+	home, err := os.MkdirTemp("", "autonomix-gpg-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(home)
+
+	keyPath := filepath.Join(home, "key.asc")
+	sigPath := filepath.Join(home, "file.sig")
+	if err := os.WriteFile(keyPath, []byte(armoredKey), 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(sigPath, sig, 0600); err != nil {
+		return err
+	}
+
+	if out, err := exec.Command("gpg", "--homedir", home, "--batch", "--quiet", "--import", keyPath).CombinedOutput(); err != nil {
+		return unusable("importing gpg key: %s", strings.TrimSpace(string(out)))
+	}
+
+	var status bytes.Buffer
+	cmd := exec.Command("gpg", "--homedir", home, "--batch", "--status-fd", "1", "--verify", sigPath, path)
+	cmd.Stdout = &status
+	cmd.Run() // the status output decides, not the exit code
+
+	// Only a signature gpg could check against the key counts as bad;
+	// NO_PUBKEY, NODATA and the like mean it cannot be checked at all
+	bad := false
+	for _, line := range strings.Split(status.String(), "\n") {
+		switch {
+		case strings.HasPrefix(line, "[GNUPG:] VALIDSIG "):
+			return nil
+		case strings.HasPrefix(line, "[GNUPG:] BADSIG "):
+			bad = true
+		}
+	}
+	if bad {
+		return errors.New("gpg signature does not match")
+	}
+	return unusable("gpg cannot check the signature with the pinned key")
+}
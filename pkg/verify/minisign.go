@@ -0,0 +1,123 @@
+package verify
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Minisign signature algorithms: "Ed" signs the file itself, "ED" (the
+// default since minisign 0.8) signs its BLAKE2b-512 hash.
+const (
+	minisignPure      = "Ed"
+	minisignPrehashed = "ED"
+)
+
+type minisignKey struct {
+	id  [8]byte
+	key ed25519.PublicKey
+}
+
+// parseMinisignKey accepts a key file or just its base64 line.
+func parseMinisignKey(text string) (*minisignKey, error) {
+	var line string
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimSpace(l)
+		if l != "" && !strings.HasPrefix(l, "untrusted comment:") {
+			line = l
+			break
+		}
+	}
+	raw, err := base64.StdEncoding.DecodeString(line)
+	if err != nil || len(raw) != 2+8+ed25519.PublicKeySize {
+		return nil, unusable("invalid minisign public key")
+	}
+	if string(raw[:2]) != minisignPure {
+		return nil, unusable("unsupported minisign key algorithm %q", raw[:2])
+	}
+	k := &minisignKey{key: ed25519.PublicKey(raw[10:])}
+	copy(k.id[:], raw[2:10])
+	return k, nil
+}
+
+// verifyMinisign checks a .minisig file, including its trusted comment.
+func verifyMinisign(pubKey, path string, sigFile []byte) error {
+	key, err := parseMinisignKey(pubKey)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(sigFile), "\r\n", "\n"), "\n")
+	if len(lines) < 4 {
+		return unusable("malformed minisign signature")
+	}
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil || len(sig) != 2+8+ed25519.SignatureSize {
+		return unusable("malformed minisign signature")
+	}
+	comment, ok := strings.CutPrefix(lines[2], "trusted comment: ")
+	if !ok {
+		return unusable("malformed minisign signature: missing trusted comment")
+	}
+	globalSig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[3]))
+	if err != nil || len(globalSig) != ed25519.SignatureSize {
+		return unusable("malformed minisign signature: bad trusted comment signature")
+	}
+
+	if !bytes.Equal(sig[2:10], key.id[:]) {
+		return unusable("signed by key %X, not the pinned key %X", reverse(sig[2:10]), reverse(key.id[:]))
+	}
+
+	var message []byte
+	switch alg := string(sig[:2]); alg {
+	case minisignPure:
+		message, err = os.ReadFile(path)
+	case minisignPrehashed:
+		message, err = blake2bFile(path)
+	default:
+		return unusable("unsupported minisign signature algorithm %q", alg)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(key.key, message, sig[10:]) {
+		return errors.New("minisign signature does not match")
+	}
+	if !ed25519.Verify(key.key, append(append([]byte{}, sig[10:]...), comment...), globalSig) {
+		return errors.New("minisign trusted comment signature does not match")
+	}
+	return nil
+}
+
+func blake2bFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, err := blake2b.New512(nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// reverse returns a key ID in the byte order minisign prints it.
+func reverse(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i := range b {
+		r[len(b)-1-i] = b[i]
+	}
+	return r
+}
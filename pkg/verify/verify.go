@@ -0,0 +1,274 @@
+// Package verify checks detached signatures published alongside release
+// assets against public keys pinned for an app.
+package verify
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+)
+
+// Policy decides what happens when a download cannot be verified.
+type Policy string
+
+const (
+	// PolicyWarn installs unsigned or unverifiable downloads, reporting why.
+	// A signature that does not match a pinned key still blocks the install.
+	PolicyWarn Policy = "warn"
+	// PolicyRequire only installs downloads with a valid signature.
+	PolicyRequire Policy = "require"
+	// PolicyOff skips signature checks entirely.
+	PolicyOff Policy = "off"
+)
+
+// Key types accepted in config.PublicKey.
+const (
+	KeyMinisign = "minisign"
+	KeyCosign   = "cosign"
+	KeyGPG      = "gpg"
+)
+
+// maxSignatureSize bounds how much of a signature or checksum asset is read.
+const maxSignatureSize = 1 << 20
+
+// ParsePolicy validates a policy from the config; empty means PolicyWarn.
+func ParsePolicy(s string) (Policy, error) {
+	switch p := Policy(s); p {
+	case "":
+		return PolicyWarn, nil
+	case PolicyWarn, PolicyRequire, PolicyOff:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown signature policy %q (want warn, require or off)", s)
+}
+
+// Trust is the policy and pinned keys a download is verified against.
+type Trust struct {
+	Policy Policy
+	Keys   []config.PublicKey
+}
+
+// ForApp returns the trust settings configured for app.
+func ForApp(app config.App) (Trust, error) {
+	policy, err := ParsePolicy(app.SignaturePolicy)
+	if err != nil {
+		return Trust{}, fmt.Errorf("%s: %w", app.Name, err)
+	}
+	return Trust{Policy: policy, Keys: app.PublicKeys}, nil
+}
+
+// Status is the outcome of a signature check.
+type Status string
+
+const (
+	Verified Status = "verified"
+	Unsigned Status = "unsigned" // the release has no signature for the asset
+	NoKey    Status = "no key"   // signed, but no pinned key can check the signature
+	Invalid  Status = "invalid"  // a pinned key rejected the signature
+	Skipped  Status = "skipped"  // the policy is off
+)
+
+// Result describes how a download's signature was checked.
+type Result struct {
+	Status    Status
+	Signature string // signature asset name
+	KeyType   string
+	Detail    string
+}
+
+func (r Result) String() string {
+	switch {
+	case r.Status == Verified:
+		return fmt.Sprintf("%s (%s, %s)", r.Status, r.KeyType, r.Signature)
+	case r.Detail != "":
+		return fmt.Sprintf("%s (%s)", r.Status, r.Detail)
+	case r.Signature != "":
+		return fmt.Sprintf("%s (%s)", r.Status, r.Signature)
+	}
+	return string(r.Status)
+}
+
+// SignatureError is returned when the policy forbids installing a download.
+type SignatureError struct {
+	Asset  string
+	Policy Policy
+	Result Result
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("signature check failed for %s: %s (policy %s)", e.Asset, e.Result, e.Policy)
+}
+
+// ChecksumList is a release asset listing the download's verified checksum,
+// as it was read when the checksum was taken from it.
+type ChecksumList struct {
+	Name string
+	Data []byte
+}
+
+// Verify checks the file at path, downloaded from asset, against the
+// signatures in release. list, if not nil, is the checksum list the file was
+// verified against; a signature on it vouches for the file as well, which is
+// how goreleaser signs releases. The signature is checked on list.Data, the
+// very bytes the checksum came from. The returned error is a
+// *SignatureError when trust's policy forbids installing the file.
+func Verify(path string, release *github.Release, asset github.Asset, list *ChecksumList, trust Trust) (Result, error) {
+	if trust.Policy == PolicyOff {
+		return Result{Status: Skipped}, nil
+	}
+
+	result := check(path, release, asset, list, trust.Keys)
+	if result.Status == Invalid || (trust.Policy == PolicyRequire && result.Status != Verified) {
+		return result, &SignatureError{Asset: asset.Name, Policy: trust.Policy, Result: result}
+	}
+	return result, nil
+}
+
+// target is a file whose signature vouches for the download.
+type target struct {
+	name string
+	path string
+}
+
+func check(path string, release *github.Release, asset github.Asset, list *ChecksumList, keys []config.PublicKey) Result {
+	targets := []target{{asset.Name, path}}
+	if list != nil && len(FindSignatures(release, list.Name)) > 0 {
+		if tmp, err := writeTemp(list.Data); err == nil {
+			defer os.Remove(tmp)
+			targets = append(targets, target{list.Name, tmp})
+		}
+	}
+
+	var signature string
+	var failures, unchecked []string
+	for _, t := range targets {
+		for _, sig := range FindSignatures(release, t.name) {
+			if signature == "" {
+				signature = sig.Name
+			}
+			candidates := keysFor(sig.Name, keys)
+			if len(candidates) == 0 {
+				continue
+			}
+			data, err := github.FetchAsset(sig, maxSignatureSize)
+			if err != nil {
+				unchecked = append(unchecked, fmt.Sprintf("%s: %v", sig.Name, err))
+				continue
+			}
+			for _, key := range candidates {
+				err := verifyWith(key, t.path, sig.Name, data)
+				var notApplicable *unusableError
+				switch {
+				case err == nil:
+					return Result{Status: Verified, Signature: sig.Name, KeyType: key.Type}
+				case errors.As(err, &notApplicable):
+					unchecked = append(unchecked, fmt.Sprintf("%s: %v", sig.Name, err))
+				default:
+					failures = append(failures, fmt.Sprintf("%s: %v", sig.Name, err))
+				}
+			}
+		}
+	}
+
+	switch {
+	case signature == "":
+		return Result{Status: Unsigned}
+	case len(failures) > 0:
+		return Result{Status: Invalid, Signature: signature, Detail: strings.Join(failures, "; ")}
+	default:
+		return Result{Status: NoKey, Signature: signature, Detail: strings.Join(unchecked, "; ")}
+	}
+}
+
+// unusableError is returned when a key cannot check a signature at all: the
+// signature is in another format or was made by another key, or the key is
+// unusable. Unlike a signature that fails to verify, it says nothing about
+// the file, so it is reported as NoKey rather than Invalid.
+type unusableError struct {
+	msg string
+}
+
+func (e *unusableError) Error() string { return e.msg }
+
+func unusable(format string, args ...any) error {
+	return &unusableError{msg: fmt.Sprintf(format, args...)}
+}
+
+// signatureExts are the detached signature suffixes, most specific first.
+var signatureExts = []string{".minisig", ".bundle", ".sig", ".asc"}
+
+// FindSignatures returns the detached signatures for the asset called name.
+func FindSignatures(release *github.Release, name string) []github.Asset {
+	if release == nil {
+		return nil
+	}
+	var sigs []github.Asset
+	for _, ext := range signatureExts {
+		for _, a := range release.Assets {
+			if strings.EqualFold(a.Name, name+ext) {
+				sigs = append(sigs, a)
+			}
+		}
+	}
+	return sigs
+}
+
+// keysFor returns the pinned keys able to check a signature file.
+func keysFor(sigName string, keys []config.PublicKey) []config.PublicKey {
+	lower := strings.ToLower(sigName)
+	var types []string
+	switch {
+	case strings.HasSuffix(lower, ".minisig"):
+		types = []string{KeyMinisign}
+	case strings.HasSuffix(lower, ".bundle"):
+		types = []string{KeyCosign}
+	case strings.HasSuffix(lower, ".asc"):
+		types = []string{KeyGPG}
+	case strings.HasSuffix(lower, ".sig"):
+		// Cosign writes base64 .sig files, GPG binary ones
+		types = []string{KeyCosign, KeyGPG}
+	}
+
+	var matched []config.PublicKey
+	for _, t := range types {
+		for _, key := range keys {
+			if key.Type == t {
+				matched = append(matched, key)
+			}
+		}
+	}
+	return matched
+}
+
+func verifyWith(key config.PublicKey, path, sigName string, sig []byte) error {
+	switch key.Type {
+	case KeyMinisign:
+		return verifyMinisign(key.Key, path, sig)
+	case KeyCosign:
+		if strings.HasSuffix(strings.ToLower(sigName), ".bundle") {
+			return verifyCosignBundle(key.Key, path, sig)
+		}
+		return verifyCosign(key.Key, path, sig)
+	case KeyGPG:
+		return verifyGPG(key.Key, path, sig)
+	}
+	return unusable("unknown key type %q", key.Type)
+}
+
+// writeTemp writes data, such as a checksum list, into a temporary file.
+func writeTemp(data []byte) (string, error) {
+	f, err := os.CreateTemp("", "autonomix-verify-*")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
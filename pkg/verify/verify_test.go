@@ -0,0 +1,308 @@
+package verify
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+
+	"github.com/tim/autonomix-cli/config"
+	"github.com/tim/autonomix-cli/pkg/github"
+)
+
+var payload = []byte("app package contents\n")
+
+// serveRelease serves files as release assets and returns the release.
+func serveRelease(t *testing.T, files map[string][]byte) *github.Release {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, ok := files[r.URL.Path[1:]]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("GITHUB_TOKEN", "")
+
+	rel := &github.Release{TagName: "v1.0.0", Assets: []github.Asset{{Name: "app.deb"}}}
+	for name := range files {
+		rel.Assets = append(rel.Assets, github.Asset{Name: name, BrowserDownloadURL: server.URL + "/" + name})
+	}
+	return rel
+}
+
+func writePayload(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "app.deb")
+	if err := os.WriteFile(path, payload, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// minisignPair generates a minisign key and a signer using algorithm alg.
+func minisignPair(t *testing.T, alg string) (string, func([]byte) []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 8)
+	rand.Read(id)
+	key := "untrusted comment: minisign public key\n" +
+		base64.StdEncoding.EncodeToString(append(append([]byte("Ed"), id...), pub...)) + "\n"
+
+	sign := func(message []byte) []byte {
+		if alg == minisignPrehashed {
+			sum := blake2b.Sum512(message)
+			message = sum[:]
+		}
+		sig := ed25519.Sign(priv, message)
+		comment := "timestamp:1700000000\tfile:app.deb"
+		global := ed25519.Sign(priv, append(append([]byte{}, sig...), comment...))
+		return []byte(fmt.Sprintf("untrusted comment: signature\n%s\ntrusted comment: %s\n%s\n",
+			base64.StdEncoding.EncodeToString(append(append([]byte(alg), id...), sig...)),
+			comment,
+			base64.StdEncoding.EncodeToString(global)))
+	}
+	return key, sign
+}
+
+// cosignPair generates an ECDSA P-256 key and a signer.
+func cosignPair(t *testing.T) (string, func([]byte) []byte) {
+	t.Helper()
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	sign := func(message []byte) []byte {
+		digest := sha256.Sum256(message)
+		sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sig
+	}
+	return key, sign
+}
+
+func TestVerifyMinisign(t *testing.T) {
+	for _, alg := range []string{minisignPure, minisignPrehashed} {
+		t.Run(alg, func(t *testing.T) {
+			key, sign := minisignPair(t, alg)
+			rel := serveRelease(t, map[string][]byte{"app.deb.minisig": sign(payload)})
+			trust := Trust{Policy: PolicyRequire, Keys: []config.PublicKey{{Type: KeyMinisign, Key: key}}}
+
+			result, err := Verify(writePayload(t), rel, rel.Assets[0], nil, trust)
+			if err != nil || result.Status != Verified {
+				t.Fatalf("Verify() = %s, %v; want verified", result, err)
+			}
+		})
+	}
+}
+
+func TestVerifyMinisign_Tampered(t *testing.T) {
+	key, sign := minisignPair(t, minisignPrehashed)
+	rel := serveRelease(t, map[string][]byte{"app.deb.minisig": sign([]byte("something else"))})
+	trust := Trust{Policy: PolicyWarn, Keys: []config.PublicKey{{Type: KeyMinisign, Key: key}}}
+
+	// A bad signature blocks the install even under the warn policy
+	result, err := Verify(writePayload(t), rel, rel.Assets[0], nil, trust)
+	var sigErr *SignatureError
+	if !errors.As(err, &sigErr) || result.Status != Invalid {
+		t.Fatalf("Verify() = %s, %v; want invalid with a SignatureError", result, err)
+	}
+}
+
+func TestVerifyCosign(t *testing.T) {
+	key, sign := cosignPair(t)
+	sig := sign(payload)
+	encoded := base64.StdEncoding.EncodeToString(sig)
+	legacy, _ := json.Marshal(map[string]any{"base64Signature": encoded})
+	sigstore, _ := json.Marshal(map[string]any{
+		"mediaType":        "application/vnd.dev.sigstore.bundle.v0.3+json",
+		"messageSignature": map[string]any{"signature": encoded},
+	})
+
+	tests := map[string][]byte{
+		"app.deb.sig":    []byte(encoded + "\n"),
+		"app.deb.bundle": legacy,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			rel := serveRelease(t, map[string][]byte{name: data})
+			trust := Trust{Policy: PolicyRequire, Keys: []config.PublicKey{{Type: KeyCosign, Key: key}}}
+			result, err := Verify(writePayload(t), rel, rel.Assets[0], nil, trust)
+			if err != nil || result.Status != Verified || result.Signature != name {
+				t.Fatalf("Verify() = %s, %v; want verified by %s", result, err, name)
+			}
+		})
+	}
+
+	t.Run("sigstore bundle", func(t *testing.T) {
+		rel := serveRelease(t, map[string][]byte{"app.deb.bundle": sigstore})
+		trust := Trust{Policy: PolicyRequire, Keys: []config.PublicKey{{Type: KeyCosign, Key: key}}}
+		if result, err := Verify(writePayload(t), rel, rel.Assets[0], nil, trust); err != nil || result.Status != Verified {
+			t.Fatalf("Verify() = %s, %v; want verified", result, err)
+		}
+	})
+
+	t.Run("wrong key", func(t *testing.T) {
+		otherKey, _ := cosignPair(t)
+		rel := serveRelease(t, map[string][]byte{"app.deb.sig": []byte(encoded)})
+		trust := Trust{Policy: PolicyWarn, Keys: []config.PublicKey{{Type: KeyCosign, Key: otherKey}}}
+		if result, err := Verify(writePayload(t), rel, rel.Assets[0], nil, trust); err == nil || result.Status != Invalid {
+			t.Fatalf("Verify() = %s, %v; want invalid", result, err)
+		}
+	})
+}
+
+func TestVerify_SignedChecksumFile(t *testing.T) {
+	key, sign := cosignPair(t)
+	sums := []byte(fmt.Sprintf("%x  app.deb\n", sha256.Sum256(payload)))
+	rel := serveRelease(t, map[string][]byte{
+		"checksums.txt":     sums,
+		"checksums.txt.sig": []byte(base64.StdEncoding.EncodeToString(sign(sums))),
+	})
+	trust := Trust{Policy: PolicyRequire, Keys: []config.PublicKey{{Type: KeyCosign, Key: key}}}
+
+	result, err := Verify(writePayload(t), rel, rel.Assets[0], &ChecksumList{Name: "checksums.txt", Data: sums}, trust)
+	if err != nil || result.Status != Verified || result.Signature != "checksums.txt.sig" {
+		t.Fatalf("Verify() = %s, %v; want verified by checksums.txt.sig", result, err)
+	}
+
+	// The signature is checked on the list the checksum came from, not on
+	// another download of it
+	other := []byte(fmt.Sprintf("%x  app.deb\n", sha256.Sum256([]byte("other"))))
+	result, err = Verify(writePayload(t), rel, rel.Assets[0], &ChecksumList{Name: "checksums.txt", Data: other}, trust)
+	if err == nil || result.Status != Invalid {
+		t.Fatalf("Verify() of another list = %s, %v; want invalid", result, err)
+	}
+}
+
+func TestVerify_Policy(t *testing.T) {
+	key, sign := minisignPair(t, minisignPrehashed)
+	signed := serveRelease(t, map[string][]byte{"app.deb.minisig": sign(payload)})
+	unsigned := &github.Release{Assets: []github.Asset{{Name: "app.deb"}}}
+	keys := []config.PublicKey{{Type: KeyMinisign, Key: key}}
+
+	tests := []struct {
+		name    string
+		release *github.Release
+		trust   Trust
+		want    Status
+		wantErr bool
+	}{
+		{"warn unsigned", unsigned, Trust{Policy: PolicyWarn, Keys: keys}, Unsigned, false},
+		{"require unsigned", unsigned, Trust{Policy: PolicyRequire, Keys: keys}, Unsigned, true},
+		{"warn no key", signed, Trust{Policy: PolicyWarn}, NoKey, false},
+		{"require no key", signed, Trust{Policy: PolicyRequire}, NoKey, true},
+		{"require wrong key type", signed, Trust{Policy: PolicyRequire, Keys: []config.PublicKey{{Type: KeyGPG}}}, NoKey, true},
+		{"off", unsigned, Trust{Policy: PolicyOff}, Skipped, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Verify(writePayload(t), tt.release, tt.release.Assets[0], nil, tt.trust)
+			if result.Status != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("Verify() = %s, %v; want %s, error %v", result, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVerify_UncheckableSignature(t *testing.T) {
+	cosignKey, _ := cosignPair(t)
+	minisignKey, _ := minisignPair(t, minisignPrehashed)
+	_, otherSign := minisignPair(t, minisignPrehashed)
+
+	tests := []struct {
+		name  string
+		files map[string][]byte
+		keys  []config.PublicKey
+	}{
+		// A binary GPG signature is not a cosign one
+		{"gpg .sig with a cosign key", map[string][]byte{"app.deb.sig": {0x88, 0x75, 0x04, 0x00, 0x16, 0x0a}},
+			[]config.PublicKey{{Type: KeyCosign, Key: cosignKey}}},
+		{"minisign by another key", map[string][]byte{"app.deb.minisig": otherSign(payload)},
+			[]config.PublicKey{{Type: KeyMinisign, Key: minisignKey}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rel := serveRelease(t, tt.files)
+			result, err := Verify(writePayload(t), rel, rel.Assets[0], nil, Trust{Policy: PolicyWarn, Keys: tt.keys})
+			if err != nil || result.Status != NoKey {
+				t.Errorf("Verify() = %s, %v; want no key without an error", result, err)
+			}
+		})
+	}
+}
+
+func TestParsePolicy(t *testing.T) {
+	if p, err := ParsePolicy(""); err != nil || p != PolicyWarn {
+		t.Errorf("ParsePolicy(\"\") = %q, %v; want warn", p, err)
+	}
+	if _, err := ParsePolicy("strict"); err == nil {
+		t.Error("ParsePolicy(\"strict\") succeeded, want an error")
+	}
+}
+
+func TestVerifyGPG(t *testing.T) {
+	if _, err := exec.LookPath("gpg"); err != nil {
+		t.Skip("gpg is not installed")
+	}
+	home := t.TempDir()
+	gpg := func(args ...string) []byte {
+		t.Helper()
+		out, err := exec.Command("gpg", append([]string{"--homedir", home, "--batch", "--pinentry-mode", "loopback", "--passphrase", ""}, args...)...).Output()
+		if err != nil {
+			t.Skipf("gpg %v failed: %v", args, err)
+		}
+		return out
+	}
+	gpg("--quick-generate-key", "Release Signer <release@example.com>", "ed25519", "sign", "never")
+	key := gpg("--armor", "--export", "release@example.com")
+
+	path := writePayload(t)
+	sigPath := filepath.Join(t.TempDir(), "app.deb.asc")
+	gpg("--armor", "--detach-sign", "--output", sigPath, path)
+	sig, err := os.ReadFile(sigPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rel := serveRelease(t, map[string][]byte{"app.deb.asc": sig})
+	trust := Trust{Policy: PolicyRequire, Keys: []config.PublicKey{{Type: KeyGPG, Key: string(key)}}}
+	if result, err := Verify(path, rel, rel.Assets[0], nil, trust); err != nil || result.Status != Verified {
+		t.Fatalf("Verify() = %s, %v; want verified", result, err)
+	}
+
+	if err := os.WriteFile(path, []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if result, err := Verify(path, rel, rel.Assets[0], nil, trust); err == nil || result.Status != Invalid {
+		t.Fatalf("Verify() of a tampered file = %s, %v; want invalid", result, err)
+	}
+}
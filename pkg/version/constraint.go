@@ -0,0 +1,162 @@
+package version
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Constraint is a set of version requirements that must all hold, such as
+// ">=1.2, <2" or "~1.4". The zero Constraint allows every version.
+type Constraint struct {
+	terms []term
+	// pre allows pre-releases, which are otherwise only chosen when the
+	// constraint names one
+	pre bool
+	raw string
+}
+
+type term struct {
+	op string // "=", "!=", ">", ">=", "<" or "<="
+	v  Version
+}
+
+// ParseConstraint parses a constraint. Terms are separated by commas or
+// spaces, and each is an operator (=, !=, >, >=, <, <=) followed by a
+// version, or one of these shorthands:
+//
+//	1.2.3     exactly 1.2.3 (1.2 is 1.2.0)
+//	1.2.x     any 1.2 release, also 1.2.*
+//	~1.2.3    >=1.2.3, <1.3
+//	^1.2.3    >=1.2.3, <2 (for 0.x: ^0.2.3 is >=0.2.3, <0.3)
+//	*         any version
+func ParseConstraint(s string) (Constraint, error) {
+	c := Constraint{raw: strings.TrimSpace(s)}
+	var words []string
+	for _, w := range strings.Fields(strings.ReplaceAll(s, ",", " ")) {
+		// Join an operator written apart from its version, e.g. ">= 1.2"
+		if n := len(words); n > 0 && strings.Trim(words[n-1], "<>=!~^") == "" {
+			words[n-1] += w
+			continue
+		}
+		words = append(words, w)
+	}
+	for _, w := range words {
+		terms, err := parseTerm(w)
+		if err != nil {
+			return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", s, err)
+		}
+		for _, t := range terms {
+			c.pre = c.pre || t.v.IsPreRelease()
+		}
+		c.terms = append(c.terms, terms...)
+	}
+	return c, nil
+}
+
+func parseTerm(w string) ([]term, error) {
+	op := w[:len(w)-len(strings.TrimLeft(w, "<>=!~^"))]
+	text := w[len(op):]
+	if text == "*" || text == "x" {
+		if op != "" {
+			return nil, fmt.Errorf("%q: operator before a wildcard", w)
+		}
+		return nil, nil
+	}
+
+	wildcard := false
+	for _, suffix := range []string{".*", ".x", ".X"} {
+		if rest, ok := strings.CutSuffix(text, suffix); ok {
+			text, wildcard = rest, true
+		}
+	}
+	v, err := Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if wildcard {
+		if op != "" {
+			return nil, fmt.Errorf("%q: operator before a wildcard", w)
+		}
+		return []term{{">=", v}, {"<", bump(v, len(v.Release)-1)}}, nil
+	}
+
+	switch op {
+	case "", "=", "==":
+		return []term{{"=", v}}, nil
+	case "!=", ">", ">=", "<", "<=":
+		return []term{{op, v}}, nil
+	case "~":
+		// ~1.2.3 and ~1.2 allow patches, ~1 allows minors
+		i := 1
+		if len(v.Release) < 2 {
+			i = 0
+		}
+		return []term{{">=", v}, {"<", bump(v, i)}}, nil
+	case "^":
+		// The first non-zero component must stay
+		i := 0
+		for i < len(v.Release)-1 && v.Release[i] == 0 {
+			i++
+		}
+		return []term{{">=", v}, {"<", bump(v, i)}}, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", op)
+}
+
+// bump returns the release after v's component i, e.g. bump(1.2.3, 1) = 1.3.
+func bump(v Version, i int) Version {
+	release := append([]int(nil), v.Release[:i+1]...)
+	release[i]++
+	return Version{Epoch: v.Epoch, HasEpoch: v.HasEpoch, Release: release}
+}
+
+// IsAny reports whether c allows every release.
+func (c Constraint) IsAny() bool {
+	return len(c.terms) == 0
+}
+
+// Allows reports whether v satisfies every term of c.
+func (c Constraint) Allows(v Version) bool {
+	if v.IsPreRelease() && !c.pre {
+		return false
+	}
+	for _, t := range c.terms {
+		cmp := Compare(v, t.v)
+		var ok bool
+		switch t.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Check reports whether the version string s satisfies c. Strings that are
+// not versions never do, unless c allows anything.
+func (c Constraint) Check(s string) bool {
+	v, err := Parse(s)
+	if err != nil {
+		return c.IsAny()
+	}
+	return c.Allows(v)
+}
+
+func (c Constraint) String() string {
+	if c.raw == "" {
+		return "*"
+	}
+	return c.raw
+}
@@ -0,0 +1,55 @@
+package version
+
+import "testing"
+
+func TestConstraint(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		want       bool
+	}{
+		{"", "v1.0.0", true},
+		{"*", "v0.0.1", true},
+		{"1.2.3", "v1.2.3", true},
+		{"1.2", "1.2.0", true},
+		{"=1.2.3", "1.2.4", false},
+		{">=1.2, <2", "v1.9.9", true},
+		{">=1.2, <2", "v2.0.0", false},
+		{">= 1.2 < 2", "1.1.0", false},
+		{"!=1.3.0", "1.3.0", false},
+		{"1.4.x", "v1.4.7", true},
+		{"1.4.*", "v1.5.0", false},
+		{"~1.4.2", "1.4.9", true},
+		{"~1.4.2", "1.5.0", false},
+		{"~1.4.2", "1.4.1", false},
+		{"~1", "1.9.0", true},
+		{"^1.2.3", "1.99.0", true},
+		{"^1.2.3", "2.0.0", false},
+		{"^0.2.3", "0.2.9", true},
+		{"^0.2.3", "0.3.0", false},
+		{"^0.0.3", "0.0.4", false},
+		{"<2", "2.0.0-rc.1", false}, // pre-releases need to be asked for
+		{">=2.0.0-rc.1", "2.0.0-rc.2", true},
+		{">=2.0.0-rc.1", "v2.0.0", true},
+		{">=1", "nightly", false},
+		{"2024.x", "2024.06.01", true},
+	}
+	for _, tt := range tests {
+		c, err := ParseConstraint(tt.constraint)
+		if err != nil {
+			t.Errorf("ParseConstraint(%q): %v", tt.constraint, err)
+			continue
+		}
+		if got := c.Check(tt.version); got != tt.want {
+			t.Errorf("%q.Check(%q) = %v, want %v", tt.constraint, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestParseConstraint_Invalid(t *testing.T) {
+	for _, s := range []string{">=", "=>1.0", ">=1.x", "~x", "latest"} {
+		if _, err := ParseConstraint(s); err == nil {
+			t.Errorf("ParseConstraint(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package version
+
+// Status describes an installed version relative to the latest release.
+type Status int
+
+const (
+	// Unknown means at least one side could not be parsed or is missing.
+	Unknown Status = iota
+	UpToDate
+	UpdateAvailable
+	// Ahead means the installed version is newer than the latest release,
+	// e.g. a locally built or pre-release install.
+	Ahead
+)
+
+func (s Status) String() string {
+	switch s {
+	case UpToDate:
+		return "up to date"
+	case UpdateAvailable:
+		return "update available"
+	case Ahead:
+		return "ahead of release"
+	default:
+		return "unknown"
+	}
+}
+
+// Check compares an installed version string against the latest release tag.
+func Check(installed, latest string) Status {
+	if installed == "" || latest == "" {
+		return Unknown
+	}
+	iv, err := Parse(installed)
+	if err != nil {
+		return Unknown
+	}
+	lv, err := Parse(latest)
+	if err != nil {
+		return Unknown
+	}
+	switch Compare(iv, lv) {
+	case -1:
+		return UpdateAvailable
+	case 1:
+		return Ahead
+	default:
+		return UpToDate
+	}
+}
+
+// Equal reports whether a and b denote the same version.
+func Equal(a, b string) bool {
+	return Check(a, b) == UpToDate
+}
@@ -0,0 +1,243 @@
+// Package version parses the version strings found in release tags and
+// package managers (semver, CalVer, Debian epochs and revisions, RPM release
+// tags) into values that can be ordered.
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed version. Package revisions (the "-1" in "1.2.3-1" or
+// "-1.el9" in an RPM) and build metadata are dropped since GitHub tags
+// never carry them. A dash between numbers is only a revision after a
+// dotted core, so CalVer dates such as 2024-01-15 keep every component.
+type Version struct {
+	Epoch    int
+	HasEpoch bool
+	Release  []int
+	Pre      []string
+	Original string
+}
+
+// preReleaseRank orders well-known pre-release labels. Unknown labels sort
+// after these, lexically.
+var preReleaseRank = map[string]int{
+	"dev":      0,
+	"snapshot": 0,
+	"nightly":  0,
+	"a":        1,
+	"alpha":    1,
+	"b":        2,
+	"beta":     2,
+	"pre":      3,
+	"preview":  3,
+	"c":        4,
+	"rc":       4,
+}
+
+// Parse extracts the version from s. Leading text such as "v", "release-"
+// or "tool version " is skipped.
+func Parse(s string) (Version, error) {
+	v := Version{Original: s}
+	s = strings.TrimSpace(s)
+
+	// Debian epoch, e.g. "1:2.3.4-1"
+	if idx := strings.Index(s, ":"); idx > 0 && isDigits(s[:idx]) {
+		v.Epoch, _ = strconv.Atoi(s[:idx])
+		v.HasEpoch = true
+		s = s[idx+1:]
+	}
+
+	start := strings.IndexFunc(s, isDigit)
+	if start < 0 {
+		return v, fmt.Errorf("no version number in %q", v.Original)
+	}
+	s = s[start:]
+
+	// Numeric release components: 1.2.3, 2024.01.15, or dash-joined as in
+	// 2024-01-15. After a dotted core such as 1.2.3, "-1" is a revision.
+	dashed := false
+	for {
+		end := 0
+		for end < len(s) && isDigit(rune(s[end])) {
+			end++
+		}
+		n, err := strconv.Atoi(s[:end])
+		if err != nil {
+			return v, fmt.Errorf("invalid version %q: %w", v.Original, err)
+		}
+		v.Release = append(v.Release, n)
+		s = s[end:]
+		if len(s) < 2 || !isDigit(rune(s[1])) {
+			break
+		}
+		if s[0] == '.' || (s[0] == '-' && (len(v.Release) == 1 || dashed)) {
+			dashed = dashed || s[0] == '-'
+			s = s[1:]
+			continue
+		}
+		break
+	}
+
+	v.Pre = parsePreRelease(s)
+	return v, nil
+}
+
+// parsePreRelease interprets what follows the numeric release.
+func parsePreRelease(rest string) []string {
+	if rest == "" || rest[0] == '+' || rest[0] == ' ' || rest[0] == '(' {
+		return nil
+	}
+
+	switch rest[0] {
+	case '~':
+		// Debian sorts "~" before anything, e.g. 1.0~rc1 < 1.0
+		rest = rest[1:]
+	case '-', '.', '_':
+		if len(rest) > 1 && isDigit(rune(rest[1])) {
+			// A Debian/RPM/Arch package revision after a dotted core
+			return nil
+		}
+		rest = rest[1:]
+	}
+
+	if end := strings.IndexAny(rest, "+ ("); end >= 0 {
+		rest = rest[:end]
+	}
+	ids := strings.FieldsFunc(strings.ToLower(rest), func(r rune) bool {
+		return r == '.' || r == '-' || r == '_'
+	})
+	if len(ids) == 0 {
+		return nil
+	}
+	// Split "rc1" into "rc", "1" so it orders like "rc.1"
+	label, num := splitLabel(ids[0])
+	if _, known := preReleaseRank[label]; !known {
+		// Something like "-linux" or "-git": not a pre-release marker
+		return nil
+	}
+	pre := []string{label}
+	if num != "" {
+		pre = append(pre, num)
+	}
+	return append(pre, ids[1:]...)
+}
+
+func splitLabel(id string) (string, string) {
+	idx := strings.IndexFunc(id, isDigit)
+	if idx <= 0 {
+		return id, ""
+	}
+	return id[:idx], id[idx:]
+}
+
+// IsPreRelease reports whether v is a pre-release.
+func (v Version) IsPreRelease() bool {
+	return len(v.Pre) > 0
+}
+
+// String returns the normalized form of v.
+func (v Version) String() string {
+	parts := make([]string, len(v.Release))
+	for i, n := range v.Release {
+		parts[i] = strconv.Itoa(n)
+	}
+	s := strings.Join(parts, ".")
+	if v.HasEpoch {
+		s = fmt.Sprintf("%d:%s", v.Epoch, s)
+	}
+	if len(v.Pre) > 0 {
+		s += "-" + strings.Join(v.Pre, ".")
+	}
+	return s
+}
+
+// Compare returns -1, 0 or 1 as a is older than, equal to or newer than b.
+// Missing release components count as zero, so 1.2 == 1.2.0. Epochs are
+// only compared when both sides have one, as upstream tags never do.
+func Compare(a, b Version) int {
+	if a.HasEpoch && b.HasEpoch && a.Epoch != b.Epoch {
+		return cmpInt(a.Epoch, b.Epoch)
+	}
+
+	n := max(len(a.Release), len(b.Release))
+	for i := 0; i < n; i++ {
+		if c := cmpInt(component(a.Release, i), component(b.Release, i)); c != 0 {
+			return c
+		}
+	}
+
+	// A pre-release sorts before its final release
+	switch {
+	case len(a.Pre) == 0 && len(b.Pre) == 0:
+		return 0
+	case len(a.Pre) == 0:
+		return 1
+	case len(b.Pre) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.Pre) && i < len(b.Pre); i++ {
+		if c := comparePreID(a.Pre[i], b.Pre[i]); c != 0 {
+			return c
+		}
+	}
+	return cmpInt(len(a.Pre), len(b.Pre))
+}
+
+func comparePreID(a, b string) int {
+	an, aErr := strconv.Atoi(a)
+	bn, bErr := strconv.Atoi(b)
+	switch {
+	case aErr == nil && bErr == nil:
+		return cmpInt(an, bn)
+	case aErr == nil:
+		return -1
+	case bErr == nil:
+		return 1
+	}
+
+	ar, aKnown := preReleaseRank[a]
+	br, bKnown := preReleaseRank[b]
+	switch {
+	case aKnown && bKnown && ar != br:
+		return cmpInt(ar, br)
+	case aKnown && !bKnown:
+		return -1
+	case !aKnown && bKnown:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func component(parts []int, i int) int {
+	if i < len(parts) {
+		return parts[i]
+	}
+	return 0
+}
+
+func cmpInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if !isDigit(c) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
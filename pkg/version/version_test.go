@@ -0,0 +1,74 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.0", "1.10.0", -1},
+		{"v2.0.0", "1.99.99", 1},
+		{"0.1.1-1", "v0.1.1", 0},          // Debian revision
+		{"1:2.3.4-1ubuntu2", "v2.3.4", 0}, // epoch ignored against a tag
+		{"1:2.3.4", "2:1.0.0", -1},        // epochs on both sides
+		{"1.4.2-3.el9", "v1.4.2", 0},      // RPM release tag
+		{"1.4.2-1.fc39", "v1.4.3", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-dev", "1.0.0-alpha", -1},
+		{"1.0rc1", "1.0.0-rc.2", -1},
+		{"1.0~rc1", "1.0", -1},
+		{"2024.01.15", "2024.1.15", 0},
+		{"2024.02.01", "2023.12.31", 1},
+		{"2024-01-15", "2024.1.15", 0},
+		{"2024-01-16", "2024-01-15", 1},
+		{"nightly-2024-01-15", "nightly-2024-02-01", -1},
+		{"nightly-2024-01-15", "2024.1.15", 0},
+		{"2024.01.15-1", "2024.1.15", 0}, // revision after a dotted core
+		{"1.2.3+build.5", "1.2.3", 0},
+		{"mytool version 1.2.3 (abc123)", "v1.2.3", 0},
+		{"release-3.1", "v3.0.9", 1},
+		{"1.2.3-linux", "1.2.3", 0},
+	}
+	for _, tt := range tests {
+		a, err := Parse(tt.a)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.a, err)
+			continue
+		}
+		b, err := Parse(tt.b)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.b, err)
+			continue
+		}
+		if got := Compare(a, b); got != tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+		if got := Compare(b, a); got != -tt.want {
+			t.Errorf("Compare(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		installed, latest string
+		want              Status
+	}{
+		{"1.2.0", "v1.2", UpToDate},
+		{"1.1.9", "v1.2.0", UpdateAvailable},
+		{"1.3.0", "v1.2.0", Ahead},
+		{"detected", "v1.2.0", Unknown},
+		{"", "v1.2.0", Unknown},
+		{"1.2.0", "", Unknown},
+	}
+	for _, tt := range tests {
+		if got := Check(tt.installed, tt.latest); got != tt.want {
+			t.Errorf("Check(%q, %q) = %v, want %v", tt.installed, tt.latest, got, tt.want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -20,39 +21,17 @@ import (
 	"github.com/tim/autonomix-cli/pkg/manager"
 	"github.com/tim/autonomix-cli/pkg/packages"
 	"github.com/tim/autonomix-cli/pkg/system"
+	"github.com/tim/autonomix-cli/pkg/verify"
+	"github.com/tim/autonomix-cli/pkg/version"
 )
 
-func normalizeVersion(v string) string {
-	v = strings.TrimSpace(v)
-	// Remove "v" prefix
-	v = strings.TrimPrefix(v, "v")
-	// Remove Debian/RPM package revision suffix (e.g., "0.1.1-1" -> "0.1.1")
-	if idx := strings.LastIndex(v, "-"); idx > 0 {
-		// Only strip if what follows the dash looks like a package revision (number)
-		suffix := v[idx+1:]
-		// Check if suffix is purely numeric (Debian revision) or contains "el" (RPM dist tag)
-		if len(suffix) > 0 && (isNumeric(suffix) || strings.Contains(suffix, "el")) {
-			v = v[:idx]
-		}
-	}
-	return v
-}
-
-func isNumeric(s string) bool {
-	for _, c := range s {
-		if c < '0' || c > '9' {
-			return false
-		}
-	}
-	return len(s) > 0
-}
-
 var (
 	docStyle         = lipgloss.NewStyle().Margin(1, 2)
 	statusStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
 	installedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("42")) // Green
 	updateStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("208")) // Orange
 	notInstalledStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("250")) // Grey
+	heldStyle        = lipgloss.NewStyle().Foreground(lipgloss.Color("69")) // Blue
 )
 
 type state int
@@ -61,10 +40,13 @@ const (
 	viewList state = iota
 	viewAdd
 	viewSelectAsset
+	viewReleases
+	viewConfirmDelete
+	viewConfirmInstall
 )
 
 // Define self repo URL matching main.go to identify it
-const SelfRepoURL = "https://github.com/sgtapple/autonomix-cli"
+const SelfRepoURL = manager.SelfRepoURL
 
 type item struct {
 	app config.App
@@ -79,16 +61,31 @@ func (i item) Description() string {
 		status = "Installed: " + i.app.Version
 		style = installedStyle
 		
-		vInstalled := normalizeVersion(i.app.Version)
-		vLatest := normalizeVersion(i.app.Latest)
-		
-		if vLatest != "" && vLatest != vInstalled {
+		switch version.Check(i.app.Version, i.app.Latest) {
+		case version.UpToDate:
+			status = "Up to date: " + i.app.Version
+		case version.UpdateAvailable:
 			status = fmt.Sprintf("Update Available: %s -> %s", i.app.Version, i.app.Latest)
 			style = updateStyle
+		case version.Ahead:
+			status = fmt.Sprintf("Ahead of release: %s (latest %s)", i.app.Version, i.app.Latest)
 		}
 	}
 	
-	return fmt.Sprintf("%s (%s)", i.app.RepoURL, style.Render(status))
+	if i.app.Pinned {
+		status = "Held at " + i.app.HoldVersion
+		if i.app.Version != "" && !version.Equal(i.app.Version, i.app.HoldVersion) {
+			status += ", installed: " + i.app.Version
+		}
+		style = heldStyle
+	}
+	
+	channel := ""
+	if i.app.Channel != "" && i.app.Channel != github.ChannelStable {
+		channel = fmt.Sprintf(" [%s]", i.app.Channel)
+	}
+	
+	return fmt.Sprintf("%s%s (%s)", i.app.RepoURL, channel, style.Render(status))
 }
 func (i item) FilterValue() string { return i.app.Name }
 
@@ -104,6 +101,20 @@ type Model struct {
 	// Selection for install
 	assetList list.Model
 	selectedApp *config.App
+	selectedRelease *github.Release
+	
+	// Downloads awaiting confirmation after their checksum was verified
+	pendingInstall *downloadedMsg
+	pendingPlan *manager.UpdatePlan
+	
+	// Release history browser
+	releaseList list.Model
+	
+	// Result of the last update-all run, shown until a key is pressed
+	summary string
+
+	// Progress of the running download, shown below the status
+	progress *installer.Progress
 }
 
 // openBrowser opens the specified URL in the default browser of the user.
@@ -135,7 +146,11 @@ func NewModel(cfg *config.Config) Model {
 	l.AdditionalShortHelpKeys = func() []key.Binding {
 		return []key.Binding{
 			key.NewBinding(key.WithKeys("u"), key.WithHelp("u", "check updates")),
+			key.NewBinding(key.WithKeys("U"), key.WithHelp("U", "update all")),
 			key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete")),
+			key.NewBinding(key.WithKeys("h"), key.WithHelp("h", "release history")),
+			key.NewBinding(key.WithKeys("p"), key.WithHelp("p", "pin/unpin")),
+			key.NewBinding(key.WithKeys("f"), key.WithHelp("f", "force install")),
 			key.NewBinding(key.WithKeys("enter"), key.WithHelp("enter", "install/open")),
 		}
 	}
@@ -145,6 +160,10 @@ func NewModel(cfg *config.Config) Model {
 	assetsL.Title = "Select Package to Install"
 	assetsL.SetShowHelp(false)
 
+	releasesL := list.New([]list.Item{}, list.NewDefaultDelegate(), 0, 0)
+	releasesL.Title = "Release History"
+	releasesL.SetShowHelp(false)
+
 	ti := textinput.New()
 	ti.Placeholder = "https://github.com/owner/repo"
 	ti.Focus()
@@ -157,14 +176,25 @@ func NewModel(cfg *config.Config) Model {
 		state:     viewList,
 		config:    cfg,
 		assetList: assetsL,
+		releaseList: releasesL,
 	}
 }
 
+// WithError returns the model showing err until a key is pressed, e.g. to
+// report a config that had to be reset.
+func (m Model) WithError(err error) Model {
+	m.err = err
+	return m
+}
+
 func (m Model) Init() tea.Cmd {
 	// Check for updates for all tracked apps on startup
 	var cmds []tea.Cmd
-	for i, app := range m.config.Apps {
-		cmds = append(cmds, checkUpdateCmd(app, i))
+	for _, app := range m.config.Apps {
+		if app.Pinned {
+			continue
+		}
+		cmds = append(cmds, checkUpdateCmd(app, false))
 	}
 	return tea.Batch(cmds...)
 }
@@ -181,9 +211,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Selected asset
 				if index := m.assetList.Index(); index >= 0 && index < len(m.assetList.Items()) {
 					selectedAsset := m.assetList.Items()[index].(assetItem).asset
+					var saveCmd tea.Cmd
+					if manager.HasAssetRules(*m.selectedApp) {
+						saveCmd = m.rememberAsset(selectedAsset)
+					}
 					m.status = fmt.Sprintf("Downloading %s...", selectedAsset.Name)
 					m.state = viewList // go back to main view while installing
-					return m, downloadAssetCmd(&selectedAsset)
+					return m, tea.Batch(saveCmd, downloadAssetCmd(*m.selectedApp, m.selectedRelease, &selectedAsset))
 				}
 			case "esc", "q":
 				m.state = viewList
@@ -195,6 +229,83 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, cmd
 		}
 
+		if m.state == viewReleases {
+			switch msg.String() {
+			case "enter":
+				if index := m.releaseList.Index(); index >= 0 && index < len(m.releaseList.Items()) && m.selectedApp != nil {
+					rel := m.releaseList.Items()[index].(releaseItem).release
+					m.status = fmt.Sprintf("Fetching assets for %s...", rel.TagName)
+					m.state = viewList
+					return m, fetchReleaseAssetsCmd(*m.selectedApp, rel)
+				}
+			case "esc", "q":
+				m.state = viewList
+				m.selectedApp = nil
+				return m, nil
+			}
+			m.releaseList, cmd = m.releaseList.Update(msg)
+			return m, cmd
+		}
+
+		if m.state == viewConfirmInstall {
+			switch msg.String() {
+			case "enter", "y":
+				m.state = viewList
+				if plan := m.pendingPlan; plan != nil {
+					m.pendingPlan = nil
+					return m, installUpdatesCmd(plan)
+				}
+				if pending := m.pendingInstall; pending != nil {
+					m.pendingInstall = nil
+					m.selectedApp = &pending.app
+					return m, installDownloadedCmd(pending.app, pending.tag, pending.path)
+				}
+				return m, nil
+			case "esc", "n", "q":
+				if m.pendingPlan != nil {
+					m.pendingPlan.Discard()
+					m.summary = m.pendingPlan.Summary()
+					m.pendingPlan = nil
+				}
+				if m.pendingInstall != nil {
+					os.Remove(m.pendingInstall.path)
+					m.pendingInstall = nil
+				}
+				m.selectedApp = nil
+				m.state = viewList
+				return m, nil
+			}
+			return m, nil
+		}
+
+		if m.state == viewConfirmDelete && m.selectedApp != nil {
+			switch msg.String() {
+			case "u":
+				cmd := m.untrack(m.selectedApp.RepoURL)
+				m.selectedApp = nil
+				m.state = viewList
+				return m, cmd
+			case "x":
+				app := *m.selectedApp
+				m.state = viewList
+				uninstallCmd, err := manager.UninstallCmd(app)
+				if err != nil {
+					m.err = err
+					m.selectedApp = nil
+					return m, nil
+				}
+				m.status = fmt.Sprintf("Uninstalling %s (enter password if prompted)...", app.Name)
+				return m, tea.Exec(&execCmdAdapter{uninstallCmd}, func(err error) tea.Msg {
+					return uninstallFinishedMsg{app: app, err: err}
+				})
+			case "esc", "n", "q":
+				m.selectedApp = nil
+				m.state = viewList
+				return m, nil
+			}
+			return m, nil
+		}
+
 		if m.state == viewAdd {
 			switch msg.Type {
 			case tea.KeyEnter:
@@ -222,6 +333,10 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = nil
 				return m, nil
 			}
+			if m.summary != "" {
+				m.summary = ""
+				return m, nil
+			}
 
 			switch msg.String() {
 			case "ctrl+c", "q":
@@ -233,15 +348,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					selectedItem := m.list.Items()[index].(item)
 					
 					// Check if update available or not installed
-					vInstalled := normalizeVersion(selectedItem.app.Version)
-					vLatest := normalizeVersion(selectedItem.app.Latest)
+					installed := selectedItem.app.Version
+					latest := selectedItem.app.Latest
 					
 					// Install if not installed OR update available
-					// Note: vLatest check ensures we actually found a release on GitHub
-					if vLatest != "" && (vInstalled == "" || vLatest != vInstalled) {
+					// Note: the latest check ensures we actually found a release on GitHub.
+					// An unparseable installed version is treated as outdated, like before.
+					status := version.Check(installed, latest)
+					if latest != "" && (installed == "" || status == version.UpdateAvailable || status == version.Unknown) {
+						if selectedItem.app.Pinned {
+							return m, m.list.NewStatusMessage(statusStyle.Render(heldMessage(selectedItem.app)))
+						}
 						// Trigger install/update
 						action := "update"
-						if vInstalled == "" {
+						if installed == "" {
 							action = "install"
 						}
 						m.status = fmt.Sprintf("Fetching assets for %s...", action)
@@ -257,22 +377,68 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					openBrowser(url)
 					return m, nil
 				}
+			case "U":
+				// Update every app with an available update in one batch
+				m.status = "Checking for updates and downloading packages..."
+				apps := append([]config.App(nil), m.config.Apps...)
+				return m, prepareUpdatesCmd(apps)
+			case "p":
+				// Toggle the hold on the selected app
+				if index := m.list.Index(); index >= 0 && index < len(m.list.Items()) {
+					selected := m.list.Items()[index].(item).app
+					if !selected.Pinned && selected.Version == "" {
+						return m, m.list.NewStatusMessage(statusStyle.Render(fmt.Sprintf("%s is not installed, nothing to hold", selected.Name)))
+					}
+					var text string
+					cmds = append(cmds, m.updateApp(selected.RepoURL, func(app *config.App) {
+						if app.Pinned {
+							app.Pinned = false
+							app.HoldVersion = ""
+							text = fmt.Sprintf("%s is no longer held", app.Name)
+						} else {
+							app.Pinned = true
+							app.HoldVersion = app.Version
+							text = fmt.Sprintf("%s is now held at %s", app.Name, app.HoldVersion)
+						}
+					}))
+					cmds = append(cmds, m.list.NewStatusMessage(statusStyle.Render(text)))
+					return m, tea.Batch(cmds...)
+				}
+			case "f":
+				// Install the latest release even if the app is held
+				if index := m.list.Index(); index >= 0 && index < len(m.list.Items()) {
+					selectedItem := m.list.Items()[index].(item)
+					m.status = "Fetching assets for forced install..."
+					return m, fetchAssetsCmd(selectedItem.app)
+				}
+			case "h":
+				// Browse all releases to install a specific version
+				if index := m.list.Index(); index >= 0 && index < len(m.list.Items()) {
+					selectedItem := m.list.Items()[index].(item)
+					m.status = fmt.Sprintf("Fetching releases for %s...", selectedItem.app.Name)
+					return m, fetchReleasesCmd(selectedItem.app)
+				}
 			case "a":
 				m.state = viewAdd
 				m.input.Focus()
 				return m, textinput.Blink
 			case "d":
+				// Ask whether to only untrack or also uninstall
 				if index := m.list.Index(); index >= 0 && index < len(m.list.Items()) {
-					m.config.Apps = append(m.config.Apps[:index], m.config.Apps[index+1:]...)
-					config.Save(m.config) // Save immediately for now
-					m.list.RemoveItem(index)
+					selectedItem := m.list.Items()[index].(item)
+					m.selectedApp = &selectedItem.app
+					m.state = viewConfirmDelete
 				}
 				return m, nil
 			case "u":
 				// Check for updates for the selected item
 				if index := m.list.Index(); index >= 0 && index < len(m.list.Items()) {
 					selectedItem := m.list.Items()[index].(item)
-					return m, checkUpdateCmd(selectedItem.app, index)
+					if selectedItem.app.Pinned {
+						return m, m.list.NewStatusMessage(statusStyle.Render(heldMessage(selectedItem.app)))
+					}
+					// An explicit check skips the cache TTL
+				return m, checkUpdateCmd(selectedItem.app, true)
 				}
 			}
 		}
@@ -280,6 +446,30 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		h, v := docStyle.GetFrameSize()
 		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.assetList.SetSize(msg.Width-h, msg.Height-v)
+		m.releaseList.SetSize(msg.Width-h, msg.Height-v)
+
+	case releasesFetchedMsg:
+		m.status = ""
+		if msg.err != nil {
+			m.err = msg.err
+			return m, nil
+		}
+		if len(msg.releases) == 0 {
+			m.err = fmt.Errorf("no releases found for %s", msg.app.Name)
+			return m, nil
+		}
+		
+		items := []list.Item{}
+		for _, rel := range msg.releases {
+			items = append(items, releaseItem{release: rel, installed: msg.app.Version})
+		}
+		m.releaseList.SetItems(items)
+		m.releaseList.ResetSelected()
+		m.releaseList.Title = fmt.Sprintf("Release History for %s", msg.app.Name)
+		m.selectedApp = &msg.app
+		m.state = viewReleases
+		return m, nil
 
 	case assetsFetchedMsg:
 		if msg.err != nil && len(msg.assets) == 0 {
@@ -301,6 +491,22 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.status = ""
 		}
 		
+		// Update the app's Latest field in config now that we fetched it
+		cmds = append(cmds, m.updateApp(msg.app.RepoURL, func(app *config.App) {
+			app.Latest = msg.app.Latest
+		}))
+		m.selectedApp = &msg.app
+		m.selectedRelease = msg.release
+
+		// Skip the selection when the app's rules remember an asset
+		if manager.HasAssetRules(msg.app) {
+			if asset, ok := installer.FindRemembered(msg.assets, msg.app.AssetPattern); ok {
+				m.status = fmt.Sprintf("Downloading %s (remembered)...", asset.Name)
+				m.state = viewList
+				return m, tea.Batch(append(cmds, downloadAssetCmd(msg.app, msg.release, &asset))...)
+			}
+		}
+
 		items := []list.Item{}
 		for _, a := range msg.assets {
 			items = append(items, assetItem{asset: a})
@@ -308,16 +514,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.assetList.SetItems(items)
 		m.assetList.Title = fmt.Sprintf("Select Asset for %s", msg.app.Name)
 		m.state = viewSelectAsset
-		m.selectedApp = &msg.app
-		// Update the app's Latest field in config now that we fetched it
-		for idx, app := range m.config.Apps {
-			if app.RepoURL == msg.app.RepoURL {
-				m.config.Apps[idx].Latest = msg.app.Latest
-				config.Save(m.config)
-				break
-			}
-		}
-		return m, nil
+		return m, tea.Batch(cmds...)
 
 	case repoCheckedMsg:
 
@@ -327,50 +524,88 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil // potentially show error
 		}
 		
-		// Logic moved to manager, but here we need to insert into list
-		// Re-load config or just use the data returned? 
-		// The Msg now needs to return the added app, not just the release.
-		
-		m.config.Apps = append(m.config.Apps, msg.app)
-		// Config is already saved by manager in the logic below (see checkRepoArgCmd)
-		
-		m.list.InsertItem(len(m.list.Items()), item{app: msg.app})
+		// The manager saved the app; adopt the config it saved, which also
+		// holds whatever other processes changed
+		*m.config = *msg.cfg
 		m.state = viewList
 		m.input.Reset()
-		return m, nil
+		return m, m.syncList()
 
 	case updateCheckedMsg:
+		cmds = append(cmds, m.updateApp(msg.app.RepoURL, func(app *config.App) {
+			manager.RecordCheck(app, msg.release, msg.err)
+		}))
 		if msg.err != nil {
-			// handle error, maybe statusbar
-			return m, nil 
-		}
-		// update the item in the list
-		idx := msg.index
-		if idx >= 0 && idx < len(m.config.Apps) {
-			m.config.Apps[idx].Latest = msg.release.TagName
-			config.Save(m.config)
-			// Update list item
-			cmd = m.list.SetItem(idx, item{app: m.config.Apps[idx]})
-			cmds = append(cmds, cmd)
+			// A rejected token affects every request, so surface it
+			var authErr *github.AuthError
+			if errors.As(msg.err, &authErr) {
+				m.err = authErr
+				return m, tea.Batch(cmds...)
+			}
+			// Keep the stale data but explain why it wasn't refreshed
+			var rlErr *github.RateLimitError
+			text := msg.err.Error()
+			if !errors.As(msg.err, &rlErr) {
+				text = fmt.Sprintf("%s: update check failed: %v", msg.app.Name, msg.err)
+			}
+			cmds = append(cmds, m.list.NewStatusMessage(statusStyle.Render(text)))
+			return m, tea.Batch(cmds...)
 		}
 
+	case downloadProgressMsg:
+		m.progress = &msg.progress
+		m.status = fmt.Sprintf("Downloading %s...", msg.progress.Asset)
+		return m, waitForMsg(msg.ch)
+
 	case downloadedMsg:
-		m.status = "Installing (enter password if prompted)..."
-		// Prepare install command
-		installCmd, err := installer.GetInstallCmd(msg.path)
-		if err != nil {
-			m.err = err
+		// Show the checksum status before anything is installed
+		m.progress = nil
+		m.status = ""
+		m.pendingInstall = &msg
+		m.state = viewConfirmInstall
+		return m, nil
+
+	case installStartedMsg:
+		m.progress = nil
+		m.status = msg.status
+		if msg.err != nil {
+			m.err = msg.err
 			m.status = ""
-			os.Remove(msg.path) // Cleanup
 			return m, nil
 		}
-		
-		// Run interactive command
-		cmd = tea.Exec(&execCmdAdapter{installCmd}, func(err error) tea.Msg {
-			os.Remove(msg.path) // Cleanup after install
-			return installFinishedMsg{err: err}
-		})
-		cmds = append(cmds, cmd)
+		return m, msg.exec
+
+	case updatesPreparedMsg:
+		m.progress = nil
+		if msg.plan.Pending() == 0 {
+			return m, finishUpdatesCmd(msg.plan, nil)
+		}
+		m.status = ""
+		m.pendingPlan = msg.plan
+		m.state = viewConfirmInstall
+		return m, nil
+
+	case updatesInstalledMsg:
+		m.status = "Verifying installations..."
+		return m, finishUpdatesCmd(msg.plan, msg.err)
+
+	case updatesFinishedMsg:
+		m.status = ""
+		cmds = append(cmds, m.updateConfig(func(cfg *config.Config) error {
+			msg.plan.Apply(cfg)
+			return nil
+		}))
+		m.summary = msg.plan.Summary()
+		return m, tea.Batch(cmds...)
+
+	case uninstallFinishedMsg:
+		m.status = ""
+		m.selectedApp = nil
+		if msg.err != nil {
+			m.err = fmt.Errorf("uninstall failed: %v", msg.err)
+			return m, nil
+		}
+		return m, tea.Batch(m.untrack(msg.app.RepoURL), m.list.NewStatusMessage(statusStyle.Render(fmt.Sprintf("%s uninstalled", msg.app.Name))))
 
 	case installFinishedMsg:
 		if msg.err != nil {
@@ -388,20 +623,20 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case installedRecheckedMsg:
 		// Update the app's version and latest in config and list
 		m.status = ""
-		for idx, app := range m.config.Apps {
-			if app.RepoURL == msg.app.RepoURL {
-				m.config.Apps[idx].Version = msg.version
-				// Also update Latest to ensure we have the correct release tag
-				if msg.latest != "" {
-					m.config.Apps[idx].Latest = msg.latest
-				}
-				config.Save(m.config)
-				// Update list item
-				cmd = m.list.SetItem(idx, item{app: m.config.Apps[idx]})
-				cmds = append(cmds, cmd)
-				break
+		cmds = append(cmds, m.updateApp(msg.app.RepoURL, func(app *config.App) {
+			manager.RecordInstallation(app, msg.installation)
+			if msg.app.InstalledTag != "" {
+				manager.RecordInstalledAsset(app, msg.app.InstalledTag, msg.app.InstalledAsset, msg.app.InstalledSHA256)
 			}
-		}
+			// Installing another release moves the hold along
+			if app.Pinned && msg.installation.Version != "" {
+				app.HoldVersion = msg.installation.Version
+			}
+			// Also update Latest to ensure we have the correct release tag
+			if msg.latest != "" {
+				app.Latest = msg.latest
+			}
+		}))
 		m.selectedApp = nil
 	}
 
@@ -419,13 +654,46 @@ func (m Model) View() string {
 	}
 
 	if m.status != "" {
+		if m.progress != nil {
+			return fmt.Sprintf("\n  %s\n\n  %s\n", m.status, renderProgress(*m.progress))
+		}
 		return fmt.Sprintf("\n  %s\n", m.status)
 	}
 
+	if m.summary != "" {
+		return fmt.Sprintf("\n  Update summary:\n\n%s\n  Press any key to continue...", indent(m.summary, "  "))
+	}
+
 	if m.state == viewSelectAsset {
 		return docStyle.Render(m.assetList.View())
 	}
 
+	if m.state == viewReleases {
+		return docStyle.Render(m.releaseList.View())
+	}
+
+	if m.state == viewConfirmInstall {
+		if m.pendingPlan != nil {
+			return fmt.Sprintf(
+				"\n  Install %d update(s)?\n\n%s\n  enter: install\n  esc: cancel\n",
+				m.pendingPlan.Pending(), indent(m.pendingPlan.Verification(), "  "),
+			)
+		}
+		if m.pendingInstall != nil {
+			return fmt.Sprintf(
+				"\n  Install %s?\n\n  Checksum:  %s\n  Signature: %s\n\n  enter: install\n  esc: cancel\n",
+				m.pendingInstall.asset, m.pendingInstall.verification.Checksum, m.pendingInstall.verification.Signature,
+			)
+		}
+	}
+
+	if m.state == viewConfirmDelete && m.selectedApp != nil {
+		return fmt.Sprintf(
+			"\n  Remove %s?\n\n  u: untrack only\n  x: uninstall and untrack\n  esc: cancel\n",
+			m.selectedApp.Name,
+		)
+	}
+
 	if m.state == viewAdd {
 		return fmt.Sprintf(
 			"Enter GitHub Repo URL:\n\n%s\n\n(esc to cancel)\n",
@@ -435,37 +703,90 @@ func (m Model) View() string {
 	return docStyle.Render(m.list.View())
 }
 
+// updateConfig saves a change to the config through manager.UpdateConfig
+// and shows the saved apps, including those other processes added, e.g. with
+// "autonomix-cli add" while the TUI is open.
+func (m *Model) updateConfig(fn func(cfg *config.Config) error) tea.Cmd {
+	if err := manager.UpdateConfig(m.config, fn); err != nil {
+		return m.list.NewStatusMessage(statusStyle.Render(fmt.Sprintf("Error saving config: %v", err)))
+	}
+	return m.syncList()
+}
+
+// updateApp saves a change to the app with repoURL, see updateConfig.
+func (m *Model) updateApp(repoURL string, fn func(app *config.App)) tea.Cmd {
+	return m.updateConfig(func(cfg *config.Config) error {
+		for idx := range cfg.Apps {
+			if cfg.Apps[idx].RepoURL == repoURL {
+				fn(&cfg.Apps[idx])
+			}
+		}
+		return nil
+	})
+}
+
+// syncList shows the apps of m.config in the list.
+func (m *Model) syncList() tea.Cmd {
+	items := make([]list.Item, len(m.config.Apps))
+	for idx, app := range m.config.Apps {
+		items[idx] = item{app: app}
+	}
+	return m.list.SetItems(items)
+}
+
+// rememberAsset records the pattern of the asset picked for the selected
+// app, so later updates use the same variant without asking.
+func (m *Model) rememberAsset(asset github.Asset) tea.Cmd {
+	pattern := installer.RememberPattern(asset.Name, m.selectedRelease.TagName)
+	m.selectedApp.AssetPattern = pattern
+	return m.updateApp(m.selectedApp.RepoURL, func(app *config.App) {
+		app.AssetPattern = pattern
+	})
+}
+
+// untrack removes the app with repoURL from the config and the list.
+func (m *Model) untrack(repoURL string) tea.Cmd {
+	return m.updateConfig(func(cfg *config.Config) error {
+		for idx, app := range cfg.Apps {
+			if app.RepoURL == repoURL {
+				cfg.Apps = append(cfg.Apps[:idx], cfg.Apps[idx+1:]...)
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// heldMessage explains why an action was skipped for a pinned app.
+func heldMessage(app config.App) string {
+	return fmt.Sprintf("%s is held at %s, press f to force or p to unpin", app.Name, app.HoldVersion)
+}
+
 // Commands and Messages
 
 type repoCheckedMsg struct {
 	app config.App
+	// cfg is the config saved with the app
+	cfg *config.Config
 	err error
 }
 
 func checkRepoArgCmd(url string) tea.Cmd {
 	return func() tea.Msg {
-		// We need to load config first to pass to manager, 
-		// but Model has it. Accessing Model from Cmd is hard.
-		// However, we can just load it again or pass it...
-		// Simplified: We actually just want the logic to run.
-		// BUT: Manager saves the config. The Model has an in-memory copy.
-		// We should probably NOT save in manager if called from TUI? 
-		// Or we reload config in TUI?
-		
-		// Let's modify the flow.
-		// The TUI should call the manager.
-		
-		cfg, err := config.Load() 
-		if err != nil {
+		// The Model's config must not be touched from a command, so the
+		// manager works on a fresh copy and the Model adopts the result
+		cfg, err := config.Load()
+		var quarantined *config.QuarantineError
+		if err != nil && !errors.As(err, &quarantined) {
 			return repoCheckedMsg{err: err}
 		}
-		
+
 		res, err := manager.AddApp(cfg, url)
 		if err != nil {
 			return repoCheckedMsg{err: err}
 		}
-		
-		return repoCheckedMsg{app: res.App, err: nil}
+
+		return repoCheckedMsg{app: res.App, cfg: cfg}
 	}
 }
 
@@ -478,34 +799,86 @@ type assetsFetchedMsg struct {
 
 func fetchAssetsCmd(app config.App) tea.Cmd {
 	return func() tea.Msg {
-		rel, err := github.GetLatestRelease(app.RepoURL)
-		if err != nil {
-			return assetsFetchedMsg{err: err}
-		}
-		
-		assets, err := installer.GetCompatibleAssets(rel)
+		rel, err := github.FetchChannelRelease(app.RepoURL, app.Channel, false)
 		if err != nil {
-			// Try to get all assets as a fallback
-			allAssets := installer.GetAllAssets(rel)
-			if len(allAssets) > 0 {
-				// Return all assets with a warning in the error
-				app.Latest = rel.TagName
-				return assetsFetchedMsg{
-					assets: allAssets, 
-					app: app, 
-					release: rel, 
-					err: fmt.Errorf("warning: %v. Showing all available assets", err),
-				}
-			}
 			return assetsFetchedMsg{err: err}
 		}
 		
 		// Update app with latest release tag
 		app.Latest = rel.TagName
 		
-		return assetsFetchedMsg{assets: assets, app: app, release: rel, err: nil}
+		return assetsForRelease(app, rel)
+	}
+}
+
+// fetchReleaseAssetsCmd lists the assets of a specific release, e.g. one picked
+// from the history view. The app's Latest is left untouched.
+func fetchReleaseAssetsCmd(app config.App, rel github.Release) tea.Cmd {
+	return func() tea.Msg {
+		return assetsForRelease(app, &rel)
+	}
+}
+
+func assetsForRelease(app config.App, rel *github.Release) assetsFetchedMsg {
+	assets, err := installer.CompatibleAssetsFor(rel, manager.AssetRules(app))
+	if err != nil {
+		// Try to get all assets as a fallback
+		allAssets := installer.GetAllAssets(rel)
+		if len(allAssets) > 0 {
+			// Return all assets with a warning in the error
+			return assetsFetchedMsg{
+				assets: allAssets, 
+				app: app, 
+				release: rel, 
+				err: fmt.Errorf("warning: %v. Showing all available assets", err),
+			}
+		}
+		return assetsFetchedMsg{err: err}
+	}
+	
+	return assetsFetchedMsg{assets: assets, app: app, release: rel, err: nil}
+}
+
+type releasesFetchedMsg struct {
+	app      config.App
+	releases []github.Release
+	err      error
+}
+
+func fetchReleasesCmd(app config.App) tea.Cmd {
+	return func() tea.Msg {
+		releases, err := github.ListReleases(app.RepoURL, false)
+		return releasesFetchedMsg{app: app, releases: releases, err: err}
+	}
+}
+
+type releaseItem struct {
+	release   github.Release
+	installed string
+}
+
+func (i releaseItem) Title() string {
+	title := i.release.TagName
+	if i.release.Prerelease {
+		title += " (pre-release)"
 	}
+	return title
 }
+func (i releaseItem) Description() string {
+	date := "unpublished"
+	if !i.release.PublishedAt.IsZero() {
+		date = i.release.PublishedAt.Local().Format("2006-01-02")
+	}
+	desc := date
+	if i.release.Name != "" && i.release.Name != i.release.TagName {
+		desc += " | " + i.release.Name
+	}
+	if version.Equal(i.installed, i.release.TagName) {
+		desc += " | " + installedStyle.Render("installed")
+	}
+	return desc
+}
+func (i releaseItem) FilterValue() string { return i.release.TagName }
 
 type assetItem struct {
 	asset github.Asset
@@ -531,20 +904,36 @@ func (i assetItem) FilterValue() string { return i.asset.Name }
 
 
 type updateCheckedMsg struct {
-	index   int
+	app     config.App
 	release *github.Release
 	err     error
 }
 
-func checkUpdateCmd(app config.App, index int) tea.Cmd {
+func checkUpdateCmd(app config.App, refresh bool) tea.Cmd {
 	return func() tea.Msg {
-		rel, err := github.GetLatestRelease(app.RepoURL)
-		return updateCheckedMsg{index: index, release: rel, err: err}
+		rel, err := github.FetchChannelRelease(app.RepoURL, app.Channel, refresh)
+		return updateCheckedMsg{app: app, release: rel, err: err}
 	}
 }
 
 type downloadedMsg struct {
-	path string
+	app          config.App
+	tag          string
+	path         string
+	asset        string
+	verification installer.Verification
+}
+
+// installStartedMsg hands an interactive install command to the Update loop.
+type installStartedMsg struct {
+	status string
+	exec   tea.Cmd
+	err    error
+}
+
+type uninstallFinishedMsg struct {
+	app config.App
+	err error
 }
 
 type installFinishedMsg struct {
@@ -552,27 +941,15 @@ type installFinishedMsg struct {
 }
 
 type installedRecheckedMsg struct {
-	app     config.App
-	version string
-	latest  string
+	app          config.App
+	installation system.Installation
+	latest       string
 }
 
 func recheckInstalledCmd(app config.App) tea.Cmd {
 	return func() tea.Msg {
-		version, _, installed := system.CheckInstalled(app.Name)
-		if !installed {
-			// Try checking with repo name as well
-			parts := strings.Split(app.RepoURL, "/")
-			if len(parts) > 0 {
-				repoName := parts[len(parts)-1]
-				if repoName != app.Name {
-					if ver, _, ok := system.CheckInstalled(repoName); ok {
-						version = ver
-					}
-				}
-			}
-		}
-		return installedRecheckedMsg{app: app, version: version, latest: app.Latest}
+		inst, _ := manager.DetectInstalled(app)
+		return installedRecheckedMsg{app: app, installation: inst, latest: app.Latest}
 	}
 }
 
@@ -580,31 +957,146 @@ func recheckInstalledWithDelayCmd(app config.App) tea.Cmd {
 	return func() tea.Msg {
 		// Wait for package manager database to update
 		time.Sleep(1 * time.Second)
-		version, _, installed := system.CheckInstalled(app.Name)
-		if !installed {
-			// Try checking with repo name as well
-			parts := strings.Split(app.RepoURL, "/")
-			if len(parts) > 0 {
-				repoName := parts[len(parts)-1]
-				if repoName != app.Name {
-					if ver, _, ok := system.CheckInstalled(repoName); ok {
-						version = ver
-					}
+		inst, _ := manager.DetectInstalled(app)
+		return installedRecheckedMsg{app: app, installation: inst, latest: app.Latest}
+	}
+}
+
+// downloadProgressMsg reports a running download. The messages that follow
+// it, up to the one ending the download, are read from ch.
+type downloadProgressMsg struct {
+	progress installer.Progress
+	ch       <-chan tea.Msg
+}
+
+// withProgress runs work in the background, delivering its download progress
+// as downloadProgressMsg and then the message it returns.
+func withProgress(work func(progress installer.ProgressFunc) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		ch := make(chan tea.Msg, 1)
+		go func() {
+			msg := work(func(p installer.Progress) {
+				select {
+				case ch <- downloadProgressMsg{progress: p, ch: ch}:
+				default: // the view has not caught up, skip this update
 				}
-			}
-		}
-		return installedRecheckedMsg{app: app, version: version, latest: app.Latest}
+			})
+			ch <- msg
+		}()
+		return <-ch
 	}
 }
 
-func downloadAssetCmd(asset *github.Asset) tea.Cmd {
+func waitForMsg(ch <-chan tea.Msg) tea.Cmd {
 	return func() tea.Msg {
-		path, err := installer.DownloadAsset(asset)
+		return <-ch
+	}
+}
+
+// renderProgress draws a progress bar followed by the transfer details.
+func renderProgress(p installer.Progress) string {
+	const width = 30
+	if p.Total <= 0 {
+		return p.String()
+	}
+	filled := int(p.Fraction() * width)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	return fmt.Sprintf("%s %3.0f%%  %s", bar, p.Fraction()*100, p)
+}
+
+func downloadAssetCmd(app config.App, release *github.Release, asset *github.Asset) tea.Cmd {
+	return withProgress(func(progress installer.ProgressFunc) tea.Msg {
+		trust, err := verify.ForApp(app)
 		if err != nil {
+			return installStartedMsg{err: err}
+		}
+		path, verification, err := installer.DownloadVerifiedAsset(release, asset, trust, progress)
+		if err != nil {
+			// A checksum or signature failure is reported as is, nothing was installed
+			return installStartedMsg{err: err}
+		}
+		// Record the exact name, e.g. a flatpak's application ID, for the recheck
+		if name := installer.PackageName(path); name != "" {
+			app.PackageName = name
+			app.PackageType = string(packages.DetectType(path))
+		}
+		// Carried to the recheck, which saves it once the install succeeds
+		manager.RecordInstalledAsset(&app, release.TagName, asset.Name, verification.Checksum.Actual)
+		return downloadedMsg{app: app, tag: release.TagName, path: path, asset: asset.Name, verification: verification}
+	})
+}
+
+func installDownloadedCmd(app config.App, tag, path string) tea.Cmd {
+	return func() tea.Msg {
+		if installer.IsUserInstall(path) {
+			// AppImages are installed in the home directory, no sudo needed
+			_, err := installer.InstallUserPackage(manager.PackageFor(app, path), app.Name, tag)
+			os.Remove(path)
 			return installFinishedMsg{err: err}
 		}
-		return downloadedMsg{path: path}
+		installCmd, err := installer.GetPackageInstallCmd(manager.PackageFor(app, path))
+		if err != nil {
+			os.Remove(path) // Cleanup
+			return installStartedMsg{err: err}
+		}
+		return installStartedMsg{
+			status: "Installing (enter password if prompted)...",
+			exec: tea.Exec(&execCmdAdapter{installCmd}, func(err error) tea.Msg {
+				os.Remove(path) // Cleanup after install
+				return installFinishedMsg{err: err}
+			}),
+		}
+	}
+}
+
+func installUpdatesCmd(plan *manager.UpdatePlan) tea.Cmd {
+	return func() tea.Msg {
+		plan.InstallUserPackages()
+		installCmd, err := plan.InstallCmd()
+		if err != nil || installCmd == nil {
+			return updatesInstalledMsg{plan: plan, err: err}
+		}
+		return installStartedMsg{
+			status: fmt.Sprintf("Installing %d update(s) (enter password if prompted)...", plan.Pending()),
+			exec: tea.Exec(&execCmdAdapter{installCmd}, func(err error) tea.Msg {
+				return updatesInstalledMsg{plan: plan, err: err}
+			}),
+		}
+	}
+}
+
+type updatesPreparedMsg struct {
+	plan *manager.UpdatePlan
+}
+
+type updatesInstalledMsg struct {
+	plan *manager.UpdatePlan
+	err  error
+}
+
+type updatesFinishedMsg struct {
+	plan *manager.UpdatePlan
+}
+
+func prepareUpdatesCmd(apps []config.App) tea.Cmd {
+	return withProgress(func(progress installer.ProgressFunc) tea.Msg {
+		return updatesPreparedMsg{plan: manager.PrepareUpdates(apps, false, progress)}
+	})
+}
+
+func finishUpdatesCmd(plan *manager.UpdatePlan, installErr error) tea.Cmd {
+	return func() tea.Msg {
+		plan.Finish(installErr)
+		return updatesFinishedMsg{plan: plan}
+	}
+}
+
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
 	}
+	return strings.Join(lines, "\n") + "\n"
 }
 
 // execCmdAdapter adapts exec.Cmd to satisfy tea.ExecCommand interface